@@ -85,7 +85,23 @@ case "$1" in
             sudo chown "$2" "$3"
         fi
         ;;
+    "nginx")
+        # $2 => "test", "reload" or "reopen", no other arguments are passed to Nginx
+        case "$2" in
+            "test")
+                sudo nginx -t
+                ;;
+            "reload"|"reopen")
+                sudo nginx -s "$2"
+                ;;
+            *)
+                echo "Undefined nginx action '$2'"
+                exit 1
+                ;;
+        esac
+        ;;
     *)
         echo "Undefined action '$1'"
+        exit 1
         ;;
 esac
\ No newline at end of file
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jozefcipa/novus/internal/agent"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/spf13/cobra"
+)
+
+var rotateTokenFlag bool
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Manage the Novus agent serving the control API",
+	Long: fmt.Sprintf(`The Novus agent is a background process that serves a local HTTP/JSON API on https://%s/api.
+It can be used to list apps and routes, pause/resume/remove apps, manage global routes and re-apply configuration.
+
+All requests must contain the "Authorization: Bearer <token>" header, run "novus agent token" to get the token.`, novus.NovusInternalDomain),
+}
+
+var agentStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the Novus agent",
+	Run: func(cmd *cobra.Command, args []string) {
+		if agent.IsRunning() {
+			logger.Checkf("Novus agent is already running")
+			return
+		}
+
+		if err := agent.Start(); err != nil {
+			logger.Errorf(err.Error())
+			process.Exit(1)
+		}
+		logger.Checkf("Novus agent started")
+	},
+}
+
+var agentStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the Novus agent",
+	Run: func(cmd *cobra.Command, args []string) {
+		if agent.Stop() {
+			logger.Infof("🚫 Novus agent stopped")
+		} else {
+			logger.Hintf("Novus agent is not running.")
+		}
+	},
+}
+
+var agentStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the Novus agent is running",
+	Run: func(cmd *cobra.Command, args []string) {
+		if agent.IsRunning() {
+			logger.Checkf("Novus agent running (https://%s/api)", novus.NovusInternalDomain)
+			logger.Debugf("Agent logs are stored in %s", agent.LogFilePath())
+		} else {
+			logger.Errorf("Novus agent not running")
+			logger.Hintf("Run \"novus agent start\" to start it.")
+		}
+	},
+}
+
+var agentTokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Print the token used to authenticate API requests",
+	Run: func(cmd *cobra.Command, args []string) {
+		var token string
+		var err error
+		if rotateTokenFlag {
+			token, err = agent.RotateToken()
+		} else {
+			token, err = agent.EnsureToken()
+		}
+
+		if err != nil {
+			logger.Errorf(err.Error())
+			process.Exit(1)
+		}
+
+		fmt.Println(token)
+	},
+}
+
+// Runs the agent in the foreground, this is what the background process executes
+var agentRunCmd = &cobra.Command{
+	Use:    "run",
+	Short:  "Run the Novus agent in the foreground",
+	Hidden: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := agent.Serve(); err != nil {
+			logger.Errorf("Novus agent failed: %v", err)
+			process.Exit(1)
+		}
+	},
+}
+
+func init() {
+	agentTokenCmd.Flags().BoolVar(&rotateTokenFlag, "rotate", false, "generate a new token and invalidate the previous one")
+
+	agentCmd.AddCommand(agentStartCmd)
+	agentCmd.AddCommand(agentStopCmd)
+	agentCmd.AddCommand(agentStatusCmd)
+	agentCmd.AddCommand(agentTokenCmd)
+	agentCmd.AddCommand(agentRunCmd)
+	rootCmd.AddCommand(agentCmd)
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/jozefcipa/novus/internal/ca"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/spf13/cobra"
+)
+
+var caExportFormatFlag string
+var caExportOutFlag string
+
+var caCmd = &cobra.Command{
+	Use:   "ca",
+	Short: "Manage the Novus certificate authority",
+	Long:  "Manage the certificate authority signing the SSL certificates (~/.novus/ca or the custom CA from ~/.novus/settings.yml)",
+}
+
+var caExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the CA certificate for other devices",
+	Long: `Export the CA certificate, so phones, virtual machines or Docker containers can trust the Novus certificates.
+The PEM format is printed to the standard output unless --out is set, other formats are saved to a file.
+The certificate can be also downloaded from https://` + novus.NovusIndexDomain + `/ca or http://<LAN IP>/ca.`,
+	Annotations: map[string]string{skipStateLoadAnnotation: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		format := ca.ExportFormat(caExportFormatFlag)
+		if !slices.Contains(ca.ExportFormats, format) {
+			logger.Errorf("Unsupported format \"%s\", use one of %v", caExportFormatFlag, ca.ExportFormats)
+			process.Exit(1)
+		}
+
+		content, err := ca.Export(format)
+		if err != nil {
+			logger.Errorf("Failed to export the CA: %v", err)
+			process.Exit(1)
+		}
+
+		// PEM can be piped, e.g. `novus ca export > novus-ca.crt`
+		if caExportOutFlag == "" && format == ca.EXPORT_PEM {
+			os.Stdout.Write(content)
+			return
+		}
+
+		outPath := caExportOutFlag
+		if outPath == "" {
+			outPath = ca.DownloadFileName(format)
+		}
+		outPath, _ = filepath.Abs(outPath)
+
+		if err := os.WriteFile(outPath, content, 0644); err != nil {
+			logger.Errorf("Failed to write %s: %v", outPath, err)
+			process.Exit(1)
+		}
+		logger.Checkf("CA certificate saved to %s", outPath)
+
+		for _, address := range lanAddresses() {
+			logger.Hintf("Other devices on your network can download it from http://%s/ca", address)
+		}
+	},
+}
+
+// Private IPv4 addresses of this machine, the proxy listens on all of them
+func lanAddresses() []string {
+	addresses := []string{}
+
+	interfaceAddrs, err := net.InterfaceAddrs()
+	if err != nil {
+		logger.Debugf("Failed to list network interfaces: %v", err)
+		return addresses
+	}
+
+	for _, addr := range interfaceAddrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if ok && ipNet.IP.To4() != nil && ipNet.IP.IsPrivate() {
+			addresses = append(addresses, ipNet.IP.String())
+		}
+	}
+
+	return addresses
+}
+
+func init() {
+	caExportCmd.Flags().StringVarP(&caExportFormatFlag, "format", "f", string(ca.EXPORT_PEM), fmt.Sprintf("format of the exported certificate %v", ca.ExportFormats))
+	caExportCmd.Flags().StringVarP(&caExportOutFlag, "out", "o", "", "path of the exported file")
+
+	caCmd.AddCommand(caExportCmd)
+	rootCmd.AddCommand(caCmd)
+}
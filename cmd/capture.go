@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/capture"
+	"github.com/jozefcipa/novus/internal/config_manager"
+	"github.com/jozefcipa/novus/internal/har"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/maputils"
+	"github.com/jozefcipa/novus/internal/nginx"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/settings"
+	"github.com/jozefcipa/novus/internal/sharedtypes"
+	"github.com/jozefcipa/novus/internal/transaction"
+	"github.com/spf13/cobra"
+)
+
+var captureOutFlag string
+var captureMaxBodySizeFlag int64
+
+var captureCmd = &cobra.Command{
+	Use:   "capture [domain]",
+	Short: "Record traffic of [domain] into a HAR file",
+	Long: `Record all requests and responses (including headers and bodies) flowing through the given domain until interrupted (Ctrl+C).
+The traffic is saved as a HAR file that can be opened in browser devtools.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// The built-in proxy reads the routes from the state, so the domain can't be temporarily routed to the capture proxy
+		if settings.UseBuiltinProxy() {
+			logger.Errorf("Capturing traffic is not supported with the built-in proxy")
+			logger.Hintf("Set \"proxy: nginx\" in %s to use it.", paths.NovusSettingsFilePath)
+			process.Exit(1)
+		}
+
+		domain := args[0]
+		novusState := novus.GetState()
+
+		appName, route := findRouteByDomain(domain, *novusState)
+		if route == nil {
+			logger.Errorf("Domain \"%s\" does not exist", domain)
+			process.Exit(1)
+		}
+		appState := novusState.Apps[appName]
+		if appState.Status != novus.APP_ACTIVE {
+			logger.Errorf("App \"%s\" is paused, run \"novus resume %s\" first", appName, appName)
+			process.Exit(1)
+		}
+
+		outPath := captureOutFlag
+		if outPath == "" {
+			outPath = fmt.Sprintf("%s.har", domain)
+		}
+		outPath, _ = filepath.Abs(outPath)
+
+		proxy, err := capture.NewProxy(domain, route.Upstream, captureMaxBodySizeFlag)
+		if err != nil {
+			logger.Errorf("Invalid upstream address \"%s\": %v", route.Upstream, err)
+			process.Exit(1)
+		}
+		if err := proxy.Start(); err != nil {
+			logger.Errorf("Failed to start capture proxy: %v", err)
+			process.Exit(1)
+		}
+		proxy.OnEntry = func(entry har.Entry) {
+			fmt.Printf(
+				"%s%s%s  %s%d%s  %-6s %s  %s%.0fms%s\n",
+				logger.GRAY, entry.StartedDateTime.Format("15:04:05"), logger.RESET,
+				statusColor(entry.Response.Status), entry.Response.Status, logger.RESET,
+				entry.Request.Method, entry.Request.URL,
+				logger.GRAY, entry.Time, logger.RESET,
+			)
+		}
+
+		// Track all files that will be modified, so they can be restored if anything fails
+		transaction.Begin()
+
+		applyCaptureRoutes(appName, domain, proxy.Address())
+		logger.Successf("Capturing traffic of https://%s, press Ctrl+C to stop", domain)
+
+		// Wait until interrupted
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+		fmt.Println()
+
+		// Route the traffic directly to the upstream again
+		applyCaptureRoutes(appName, domain, "")
+		transaction.Commit()
+		proxy.Stop()
+
+		entries := proxy.Entries()
+		slices.SortStableFunc(entries, func(a, b har.Entry) int { return a.StartedDateTime.Compare(b.StartedDateTime) })
+
+		ctx := cmd.Context().Value(sharedtypes.CommandContext{}).(sharedtypes.CommandContext)
+		if err := har.New(ctx.Version, entries).WriteFile(outPath); err != nil {
+			logger.Errorf("Failed to write HAR file %s: %v", outPath, err)
+			process.Exit(1)
+		}
+
+		logger.Checkf("%d requests saved to %s", len(entries), outPath)
+	},
+}
+
+func findRouteByDomain(domain string, novusState novus.NovusState) (string, *sharedtypes.Route) {
+	for appName, appState := range novusState.Apps {
+		if appName == novus.NovusInternalAppName {
+			continue
+		}
+		for _, route := range appState.Routes {
+			if route.Domain == domain {
+				return appName, &route
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// Writes the Nginx configuration of the app with the domain routed to the given upstream (or to the original one if empty)
+// The route is only temporarily redirected to the capture proxy, so the app state is not changed
+func applyCaptureRoutes(appName string, domain string, upstream string) {
+	// The lock is only held while the config is being changed, so other commands can run during the capture
+	novus.Lock()
+	defer novus.Unlock()
+
+	// Other commands might have changed the app in the meantime, so the state is always read again
+	novusState := novus.GetState()
+	appState, exists := novusState.Apps[appName]
+	if !exists {
+		logger.Warnf("App \"%s\" has been removed during the capture", appName)
+		return
+	}
+
+	conf := config_manager.LoadConfigurationFromState(appName, *novusState)
+	conf.Routes = slices.Clone(conf.Routes)
+	for i := range conf.Routes {
+		if conf.Routes[i].Domain == domain && upstream != "" {
+			conf.Routes[i].Upstream = upstream
+		}
+	}
+	certs := maputils.MergeMaps(appState.SSLCertificates, novusState.Apps[novus.NovusInternalAppName].SSLCertificates)
+
+	// Nginx is configured directly, so the temporary upstream is not stored in the app state
+	nginx.Configure(conf, certs)
+	nginx.Reload()
+	// Nginx needs a moment to switch the workers to the new configuration
+	time.Sleep(200 * time.Millisecond)
+}
+
+func init() {
+	captureCmd.Flags().StringVarP(&captureOutFlag, "out", "o", "", "path of the HAR file (default \"<domain>.har\")")
+	captureCmd.Flags().Int64Var(&captureMaxBodySizeFlag, "max-body-size", 1024*1024, "maximum number of bytes stored for each request and response body")
+	rootCmd.AddCommand(captureCmd)
+}
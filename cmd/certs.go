@@ -0,0 +1,384 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/ca"
+	"github.com/jozefcipa/novus/internal/config"
+	"github.com/jozefcipa/novus/internal/dry_run"
+	"github.com/jozefcipa/novus/internal/fs"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/maputils"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/proxy_manager"
+	"github.com/jozefcipa/novus/internal/sharedtypes"
+	"github.com/jozefcipa/novus/internal/ssl_manager"
+	"github.com/jozefcipa/novus/internal/transaction"
+	"github.com/jozefcipa/novus/internal/tui"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var renewAllFlag bool
+var renewForceFlag bool
+
+var certsCmd = &cobra.Command{
+	Use:   "certs",
+	Short: "Manage SSL certificates",
+	Long:  "Manage SSL certificates issued by Novus (stored in ~/.novus/certs)",
+}
+
+var certsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all SSL certificates",
+	Run: func(cmd *cobra.Command, args []string) {
+		certs := listDomainCertificates(*novus.GetState())
+		if len(certs) == 0 {
+			logger.Infof("No SSL certificates have been issued yet.")
+			return
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Domain", "App", "SANs", "Expires", "Issuer"})
+		table.SetAutoFormatHeaders(false)
+		table.SetAutoWrapText(false)
+		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: true})
+		table.SetCenterSeparator("|")
+
+		hasCA := ca.Load()
+		for _, domainCert := range certs {
+			cert, err := ca.ReadCertificate(domainCert.cert.CertFilePath)
+			if err != nil {
+				logger.Debugf("Failed to read certificate %s: %v", domainCert.cert.CertFilePath, err)
+				table.Append([]string{domainCert.domain, domainCert.app, "-", formatExpiration(domainCert.cert.ExpiresAt), "file is missing"})
+				continue
+			}
+
+			// Certificates signed by a previous CA are issued again on the next serve (or renew)
+			issuer := ca.IssuerName(cert)
+			if hasCA && !ca.IsIssuedByRoot(cert) {
+				issuer += " (not the current CA)"
+			}
+
+			table.Append([]string{
+				domainCert.domain,
+				domainCert.app,
+				strings.Join(ca.SANs(cert), ", "),
+				formatExpiration(cert.NotAfter),
+				issuer,
+			})
+		}
+
+		table.Render()
+
+		if root, exists := ca.Root(); exists {
+			logger.Infof("Current CA: %s [%s]", ca.SubjectName(root), ca.CertFilePath())
+		}
+	},
+}
+
+var certsInspectCmd = &cobra.Command{
+	Use:   "inspect [domain]",
+	Short: "Show details of the SSL certificate for [domain]",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		domainCert, exists := findDomainCertificate(*novus.GetState(), args[0])
+		if !exists {
+			logger.Errorf("No SSL certificate found for domain \"%s\"", args[0])
+			logger.Hintf("Run \"novus certs list\" to see all certificates.")
+			process.Exit(1)
+		}
+
+		cert, err := ca.ReadCertificate(domainCert.cert.CertFilePath)
+		if err != nil {
+			logger.Errorf("Failed to read certificate %s: %v", domainCert.cert.CertFilePath, err)
+			logger.Hintf("Run \"novus certs renew %s\" to issue it again.", domainCert.domain)
+			process.Exit(1)
+		}
+
+		signedByCurrentCA := "no"
+		if ca.Load() && ca.IsIssuedByRoot(cert) {
+			signedByCurrentCA = "yes"
+		}
+
+		rows := [][2]string{
+			{"Domain", domainCert.domain},
+			{"App", domainCert.app},
+			{"SANs", strings.Join(ca.SANs(cert), ", ")},
+			{"Subject", cert.Subject.String()},
+			{"Issuer", cert.Issuer.String()},
+			{"Signed by current CA", signedByCurrentCA},
+			{"Serial number", ca.FormatSerialNumber(cert.SerialNumber)},
+			{"Valid from", cert.NotBefore.Local().Format(time.DateTime)},
+			{"Valid until", cert.NotAfter.Local().Format(time.DateTime) + " (" + formatExpiration(cert.NotAfter) + ")"},
+			{"Key", describePublicKey(cert)},
+			{"SHA-256 fingerprint", ca.Fingerprint(cert)},
+			{"Certificate file", domainCert.cert.CertFilePath},
+			{"Key file", domainCert.cert.KeyFilePath},
+		}
+		for _, row := range rows {
+			fmt.Printf("%s%-22s%s %s\n", logger.GRAY, row[0]+":", logger.RESET, row[1])
+		}
+	},
+}
+
+var certsRenewCmd = &cobra.Command{
+	Use:   "renew [domain?]",
+	Short: "Renew SSL certificates",
+	Long: `Issue the SSL certificate for [domain] (or all certificates with --all) again.
+Only certificates that expire within a month or are not signed by the current CA are renewed, unless --force is used.`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: lockState,
+	Run: func(cmd *cobra.Command, args []string) {
+		if (len(args) == 0) == !renewAllFlag {
+			logger.Errorf("Specify either a domain or --all")
+			process.Exit(1)
+		}
+
+		novusState := novus.GetState()
+		certs := listDomainCertificates(*novusState)
+		if !renewAllFlag {
+			domainCert, exists := findDomainCertificate(*novusState, args[0])
+			if !exists {
+				logger.Errorf("No SSL certificate found for domain \"%s\"", args[0])
+				process.Exit(1)
+			}
+			certs = []domainCertificate{domainCert}
+		}
+
+		ca.Configure()
+
+		// Track all files that will be modified, so they can be restored if anything fails
+		transaction.Begin()
+
+		renewed := 0
+		// App certificates are shared by multiple domains, but they are renewed only once
+		renewedFiles := map[string]bool{}
+		for _, domainCert := range certs {
+			if renewedFiles[domainCert.cert.CertFilePath] {
+				continue
+			}
+
+			cert, isNew := ssl_manager.RenewCert(domainCert.domain, novusState.Apps[domainCert.app], renewForceFlag)
+			renewedFiles[domainCert.cert.CertFilePath] = isNew
+			if isNew {
+				renewed++
+				logger.Checkf("SSL certificate for %s renewed", domainCert.domain)
+			} else {
+				logger.Infof("SSL certificate for %s is valid until %s", domainCert.domain, cert.ExpiresAt.Local().Format(time.DateOnly))
+			}
+		}
+
+		// The proxy must load the new certificates
+		if renewed > 0 && proxy_manager.IsRunning() {
+			proxy_manager.Reload()
+		}
+
+		if dry_run.Enabled {
+			dry_run.PrintPlan()
+			return
+		}
+
+		novus.SaveState()
+		transaction.Commit()
+
+		if renewed == 0 && !renewForceFlag {
+			logger.Hintf("Use --force to renew valid certificates as well.")
+		}
+	},
+}
+
+var certsPruneCmd = &cobra.Command{
+	Use:         "prune",
+	Short:       "Remove unused certificate files",
+	Long:        "Remove directories in ~/.novus/certs that don't belong to any certificate in the state (e.g. left behind by removed apps).",
+	Annotations: lockState,
+	Run: func(cmd *cobra.Command, args []string) {
+		unusedDirs := ssl_manager.UnusedCertificateDirs(*novus.GetState())
+		if len(unusedDirs) == 0 {
+			logger.Infof("There are no unused certificates in %s", paths.SSLCertificatesDir)
+			return
+		}
+
+		logger.Infof("Found %d unused certificate directories:", len(unusedDirs))
+		for _, dir := range unusedDirs {
+			logger.Infof("   - %s", dir)
+		}
+
+		if !dry_run.Enabled && !skipConfirmationFlag && !tui.Confirm("Do you want to delete them?") {
+			os.Exit(0)
+		}
+
+		for _, dir := range unusedDirs {
+			if err := fs.DeleteDir(dir); err != nil {
+				process.Exit(1)
+			}
+		}
+
+		if dry_run.Enabled {
+			dry_run.PrintPlan()
+			return
+		}
+
+		logger.Checkf("%d unused certificate directories removed", len(unusedDirs))
+	},
+}
+
+var certsRevokeCmd = &cobra.Command{
+	Use:   "revoke [domain]",
+	Short: "Revoke the SSL certificate for [domain]",
+	Long: `Delete the SSL certificate for [domain] and remove it from the state, so a new certificate is issued on the next "novus serve".
+The domain is not served over HTTPS until then. Certificates shared by the whole app ("certificates: app") are revoked for all its domains.`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: lockState,
+	Run: func(cmd *cobra.Command, args []string) {
+		novusState := novus.GetState()
+		domainCert, exists := findDomainCertificate(*novusState, args[0])
+		if !exists {
+			logger.Errorf("No SSL certificate found for domain \"%s\"", args[0])
+			logger.Hintf("Run \"novus certs list\" to see all certificates.")
+			process.Exit(1)
+		}
+		if domainCert.app == novus.NovusInternalAppName {
+			logger.Errorf("SSL certificate for %s is used by Novus itself and can't be revoked", domainCert.domain)
+			logger.Hintf("Run \"novus certs renew %s --force\" to issue it again.", domainCert.domain)
+			process.Exit(1)
+		}
+
+		if !dry_run.Enabled && !skipConfirmationFlag && !tui.Confirm(fmt.Sprintf("Do you want to revoke the SSL certificate for %s?", domainCert.domain)) {
+			os.Exit(0)
+		}
+
+		// Track all files that will be modified, so they can be restored if anything fails
+		transaction.Begin()
+
+		appState := novusState.Apps[domainCert.app]
+		revokedDomains := ssl_manager.RevokeCert(domainCert.domain, appState)
+
+		// The proxy must stop using the deleted certificate files
+		if appState.Status == novus.APP_ACTIVE {
+			configureProxyWithoutRevokedRoutes(domainCert.app, appState, *novusState)
+			if proxy_manager.IsRunning() {
+				proxy_manager.Reload()
+			}
+		}
+
+		if dry_run.Enabled {
+			dry_run.PrintPlan()
+			return
+		}
+
+		novus.SaveState()
+		transaction.Commit()
+
+		logger.Checkf("SSL certificate for %s revoked", strings.Join(revokedDomains, ", "))
+		if domainCert.app == novus.GlobalAppName {
+			logger.Hintf("Run \"novus serve %s <upstream>\" to issue a new certificate.", domainCert.domain)
+		} else {
+			logger.Hintf("Run \"novus serve\" in %s to issue a new certificate.", appState.Directory)
+		}
+	},
+}
+
+// Routes without a certificate are left out of the proxy configuration until the next serve issues a new one.
+// They are kept in the state, so a copy of the app state is passed to the proxy.
+func configureProxyWithoutRevokedRoutes(appName string, appState *novus.AppState, novusState novus.NovusState) {
+	routes := slices.DeleteFunc(slices.Clone(appState.Routes), func(route sharedtypes.Route) bool {
+		_, hasCert := appState.SSLCertificates[route.Domain]
+		return !hasCert
+	})
+	sslCerts := maputils.MergeMaps[sharedtypes.Certificate, sharedtypes.DomainCertificates](
+		appState.SSLCertificates,
+		novusState.Apps[novus.NovusInternalAppName].SSLCertificates,
+	)
+
+	appStateCopy := *appState
+	proxy_manager.Configure(config.NovusConfig{AppName: appName, Routes: routes}, sslCerts, &appStateCopy)
+}
+
+type domainCertificate struct {
+	domain string
+	app    string
+	cert   sharedtypes.Certificate
+}
+
+// Returns the certificates of all apps sorted by app and domain
+func listDomainCertificates(novusState novus.NovusState) []domainCertificate {
+	certs := []domainCertificate{}
+
+	appNames := maputils.MapKeys(novusState.Apps)
+	slices.Sort(appNames)
+	for _, appName := range appNames {
+		domains := maputils.MapKeys(novusState.Apps[appName].SSLCertificates)
+		slices.Sort(domains)
+		for _, domain := range domains {
+			certs = append(certs, domainCertificate{domain: domain, app: appName, cert: novusState.Apps[appName].SSLCertificates[domain]})
+		}
+	}
+
+	return certs
+}
+
+func findDomainCertificate(novusState novus.NovusState, domain string) (domainCertificate, bool) {
+	certs := listDomainCertificates(novusState)
+	idx := slices.IndexFunc(certs, func(domainCert domainCertificate) bool { return domainCert.domain == domain })
+	if idx == -1 {
+		return domainCertificate{}, false
+	}
+
+	return certs[idx], true
+}
+
+func formatExpiration(expiresAt time.Time) string {
+	days := int(time.Until(expiresAt).Hours() / 24)
+	switch {
+	case time.Now().After(expiresAt):
+		return "expired"
+	case days == 0:
+		return "today"
+	case days == 1:
+		return "in 1 day"
+	default:
+		return fmt.Sprintf("in %d days", days)
+	}
+}
+
+func describePublicKey(cert *x509.Certificate) string {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d bits", key.N.BitLen())
+	case *ecdsa.PublicKey:
+		return fmt.Sprintf("ECDSA %s", key.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return cert.PublicKeyAlgorithm.String()
+	}
+}
+
+func init() {
+	certsRenewCmd.Flags().BoolVar(&renewAllFlag, "all", false, "renew all certificates")
+	certsRenewCmd.Flags().BoolVar(&renewForceFlag, "force", false, "renew the certificates even if they are still valid")
+	certsRenewCmd.Flags().BoolVar(&dry_run.Enabled, "dry-run", false, "show what would be renewed without applying anything")
+	certsRevokeCmd.Flags().BoolVarP(&skipConfirmationFlag, "yes", "y", false, "revoke without asking for confirmation")
+	certsRevokeCmd.Flags().BoolVar(&dry_run.Enabled, "dry-run", false, "show what would be revoked without applying anything")
+	certsPruneCmd.Flags().BoolVarP(&skipConfirmationFlag, "yes", "y", false, "delete without asking for confirmation")
+	certsPruneCmd.Flags().BoolVar(&dry_run.Enabled, "dry-run", false, "show what would be deleted without applying anything")
+
+	certsCmd.AddCommand(certsListCmd)
+	certsCmd.AddCommand(certsInspectCmd)
+	certsCmd.AddCommand(certsRenewCmd)
+	certsCmd.AddCommand(certsRevokeCmd)
+	certsCmd.AddCommand(certsPruneCmd)
+	rootCmd.AddCommand(certsCmd)
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/jozefcipa/novus/internal/dashboard"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/spf13/cobra"
+)
+
+var dashboardCmd = &cobra.Command{
+	Use:   "dashboard",
+	Short: "Open a live dashboard of apps, routes and requests",
+	Long: `Open a full-screen dashboard that shows all apps and routes, status of the services,
+reachability of the upstreams and recent requests of the selected app.
+Apps can be paused, resumed, served again or removed directly from the dashboard.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := dashboard.Run(); err != nil {
+			logger.Errorf(err.Error())
+			process.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dashboardCmd)
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"github.com/jozefcipa/novus/internal/dns_server"
+	"github.com/jozefcipa/novus/internal/dnsmasq"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/spf13/cobra"
+)
+
+var dnsPortFlag string
+
+// The built-in DNS server, enabled by `dns: builtin` in ~/.novus/settings.yml.
+// It's started in the background by `novus serve` and `novus start`, so it's not meant to be called directly.
+var dnsCmd = &cobra.Command{
+	Use:    "dns",
+	Short:  "Manage the built-in Novus DNS server",
+	Hidden: true,
+}
+
+// Runs the DNS server in the foreground, this is what the background process executes
+var dnsRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the built-in DNS server in the foreground",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := dns_server.Serve(dnsPortFlag); err != nil {
+			logger.Errorf("Novus DNS server failed: %v", err)
+			process.Exit(1)
+		}
+	},
+}
+
+func init() {
+	dnsRunCmd.Flags().StringVar(&dnsPortFlag, "port", dnsmasq.DefaultPort, "port to listen on (127.0.0.1)")
+
+	dnsCmd.AddCommand(dnsRunCmd)
+	rootCmd.AddCommand(dnsCmd)
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/jozefcipa/novus/internal/agent"
+	"github.com/jozefcipa/novus/internal/ca"
+	"github.com/jozefcipa/novus/internal/dns_manager"
+	"github.com/jozefcipa/novus/internal/installer"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/proxy_manager"
+	"github.com/jozefcipa/novus/internal/settings"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the Novus setup for problems",
+	Long:  "Check installed binaries, the certificate authority, SSL certificates and running services, and report what needs to be fixed.",
+	Run: func(cmd *cobra.Command, args []string) {
+		issues := 0
+		issues += checkBinaries()
+		issues += checkCA()
+		issues += checkCertificates(*novus.GetState())
+		issues += checkServices()
+
+		if issues > 0 {
+			logger.Warnf("Found %d issue(s)", issues)
+			process.Exit(1)
+		}
+		logger.Successf("No issues found")
+	},
+}
+
+func checkBinaries() int {
+	if err := installer.CheckRequiredBinaries(); err != nil {
+		logger.Errorf(err.Error())
+		logger.Hintf("Run \"novus init\" to install it.")
+		return 1
+	}
+
+	logger.Checkf("Required binaries are installed")
+	return 0
+}
+
+func checkCA() int {
+	root, exists := ca.Root()
+	if !exists {
+		logger.Warnf("Certificate authority has not been created yet")
+		logger.Hintf("It's created by the first \"novus serve\".")
+		return 1
+	}
+
+	source := "Novus CA"
+	if settings.UseCustomCA() {
+		source = "Custom CA from " + paths.NovusSettingsFilePath
+	}
+	logger.Checkf("%s: %s [%s]", source, ca.SubjectName(root), ca.CertFilePath())
+
+	issues := 0
+	if time.Now().After(root.NotAfter) {
+		logger.Errorf("Certificate authority expired on %s", root.NotAfter.Local().Format(time.DateOnly))
+		issues++
+	}
+	if !ca.IsTrusted() {
+		logger.Errorf("Certificate authority is not trusted by the system, browsers will show certificate warnings")
+		logger.Hintf("Run \"novus serve\" to install it or add %s to the trusted certificates manually.", ca.CertFilePath())
+		issues++
+	}
+
+	switch ca.NSSTrustStatus() {
+	case ca.NSS_NOT_TRUSTED:
+		logger.Errorf("Certificate authority is not trusted by Firefox or Chrome (NSS trust store), they will show certificate warnings")
+		logger.Hintf("Run \"novus serve\" to install it.")
+		issues++
+	case ca.NSS_CERTUTIL_MISSING:
+		logger.Warnf("Firefox or Chrome (NSS trust store) might not trust the certificate authority, \"certutil\" is not installed to check it")
+		logger.Hintf("Install it (%s) and run \"novus serve\".", ca.CertutilInstallHint())
+		issues++
+	}
+
+	return issues
+}
+
+func checkCertificates(novusState novus.NovusState) int {
+	certs := listDomainCertificates(novusState)
+	if len(certs) == 0 {
+		logger.Checkf("No SSL certificates have been issued yet")
+		return 0
+	}
+
+	issues := 0
+	signedByCurrentCA := 0
+	for _, domainCert := range certs {
+		cert, err := ca.ReadCertificate(domainCert.cert.CertFilePath)
+		switch {
+		case err != nil:
+			logger.Errorf("SSL certificate for %s can't be read: %v", domainCert.domain, err)
+			issues++
+		case !ca.IsIssuedByRoot(cert):
+			logger.Warnf("SSL certificate for %s is signed by %s, not by the current CA", domainCert.domain, ca.IssuerName(cert))
+			issues++
+		case time.Now().After(cert.NotAfter):
+			logger.Errorf("SSL certificate for %s expired on %s", domainCert.domain, cert.NotAfter.Local().Format(time.DateOnly))
+			issues++
+		default:
+			signedByCurrentCA++
+		}
+	}
+
+	if issues > 0 {
+		logger.Hintf("Run \"novus certs renew --all\" to issue the certificates again.")
+	}
+	logger.Checkf("%d of %d SSL certificates are valid and signed by the current CA", signedByCurrentCA, len(certs))
+
+	return issues
+}
+
+func checkServices() int {
+	issues := 0
+
+	services := []struct {
+		name      string
+		isRunning bool
+	}{
+		{name: proxy_manager.ServerName(), isRunning: proxy_manager.IsRunning()},
+		{name: dns_manager.ServerName(), isRunning: dns_manager.IsRunning()},
+	}
+	for _, service := range services {
+		if service.isRunning {
+			logger.Checkf("%s running", service.name)
+		} else {
+			logger.Errorf("%s not running", service.name)
+			issues++
+		}
+	}
+	if issues > 0 {
+		logger.Hintf("Run \"novus start\" to start routing.")
+	}
+
+	if agent.IsRunning() {
+		logger.Checkf("Novus agent running")
+	} else {
+		logger.Warnf("Novus agent not running (control API is unavailable)")
+	}
+
+	return issues
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/journal"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var historyLimitFlag int
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show recent changes made by Novus commands",
+	Long:  "Show the journal of commands that changed the routing (serve, pause, resume, remove, trust). The last change can be reverted with `novus undo`.",
+	Run: func(cmd *cobra.Command, args []string) {
+		entries := journal.List()
+		if len(entries) == 0 {
+			logger.Infof("No changes have been recorded yet.")
+			return
+		}
+
+		if historyLimitFlag > 0 && len(entries) > historyLimitFlag {
+			entries = entries[len(entries)-historyLimitFlag:]
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"#", "Time", "Command", "App", "Changes", ""})
+		table.SetAutoFormatHeaders(false)
+		table.SetAutoWrapText(false)
+		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: true})
+		table.SetCenterSeparator("|")
+
+		// Newest first
+		for i := len(entries) - 1; i >= 0; i-- {
+			entry := entries[i]
+
+			command := strings.TrimSpace(entry.Command + " " + strings.Join(entry.Args, " "))
+			note := ""
+			if entry.Undone {
+				note = "undone"
+			}
+			if entry.UndoOf != 0 {
+				command = "undo #" + strconv.Itoa(entry.UndoOf)
+			}
+
+			table.Append([]string{
+				strconv.Itoa(entry.ID),
+				entry.Time.Local().Format(time.DateTime),
+				command,
+				entry.App,
+				entry.Changes(),
+				note,
+			})
+		}
+
+		table.Render()
+	},
+}
+
+func init() {
+	historyCmd.Flags().IntVarP(&historyLimitFlag, "limit", "n", 20, "number of entries to show (0 shows all)")
+	rootCmd.AddCommand(historyCmd)
+}
@@ -2,31 +2,47 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"strings"
 
 	"github.com/jozefcipa/novus/internal/config"
 	"github.com/jozefcipa/novus/internal/config_manager"
 	"github.com/jozefcipa/novus/internal/homebrew"
+	"github.com/jozefcipa/novus/internal/installer"
 	"github.com/jozefcipa/novus/internal/logger"
 	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/process"
 	"github.com/jozefcipa/novus/internal/stringutils"
 	"github.com/jozefcipa/novus/internal/tui"
 	"github.com/spf13/cobra"
 )
 
+var installerFlag string
+
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize Novus configuration",
 	Long:  fmt.Sprintf("Initialize Novus configuration by creating the %s file and installs all required binaries if not installed yet.", config.ConfigFileName),
 	Run: func(cmd *cobra.Command, args []string) {
-		// Install nginx, dnsmasq and mkcert if not installed
-		if err := homebrew.InstallBinaries(); err != nil {
+		// Install nginx and dnsmasq if not installed
+		pkgInstaller := installer.Detect()
+		if installerFlag != "" {
+			var err error
+			if pkgInstaller, err = installer.Get(installerFlag); err != nil {
+				logger.Errorf(err.Error())
+				process.Exit(1)
+			}
+		}
+
+		if err := installer.InstallBinaries(pkgInstaller); err != nil {
 			logger.Errorf(err.Error())
 
 			if _, ok := err.(*homebrew.HomebrewMissingError); ok {
 				logger.Hintf("You can install it from %shttps://brew.sh/%s", logger.UNDERLINE, logger.RESET)
 			}
-			os.Exit(1)
+			if _, ok := err.(*installer.BinaryVersionError); ok {
+				logger.Hintf("Upgrade it with your package manager and run \"novus init\" again.")
+			}
+			process.Exit(1)
 		}
 
 		// Check if novus.yml config exists
@@ -39,7 +55,7 @@ var initCmd = &cobra.Command{
 			err := config_manager.CreateNewConfiguration(appName, *novus.GetState())
 			if err != nil {
 				logger.Errorf(err.Error())
-				os.Exit(1)
+				process.Exit(1)
 			}
 			logger.Successf("Novus has been initialized.")
 			logger.Hintf("Open %s to add your route definitions.", config.ConfigFileName)
@@ -51,5 +67,6 @@ var initCmd = &cobra.Command{
 }
 
 func init() {
+	initCmd.Flags().StringVar(&installerFlag, "installer", "", fmt.Sprintf("package manager used to install the dependencies (%s), detected automatically by default", strings.Join(installer.Names(), ", ")))
 	rootCmd.AddCommand(initCmd)
 }
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/config"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/maputils"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/request_log"
+	"github.com/spf13/cobra"
+)
+
+var followLogsFlag bool
+var statusLogsFlag string
+var sinceLogsFlag string
+var jsonLogsFlag bool
+var errorLogsFlag bool
+
+// Nginx writes timestamps in the error log in this format
+const nginxErrorLogTimeFormat = "2006/01/02 15:04:05"
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [app-name|domain]",
+	Short: "Show request logs for [app-name] or [domain]",
+	Long: `Show requests proxied by Novus for the given app or domain.
+If no argument is provided, the app configured in the current directory is used, or all apps if there is none.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		appNames, domains := resolveLogsTarget(args)
+
+		statuses, err := request_log.ParseStatusFilter(statusLogsFlag)
+		if err != nil {
+			logger.Errorf("Invalid --status value: %v", err)
+			process.Exit(1)
+		}
+		since, err := request_log.ParseSince(sinceLogsFlag)
+		if err != nil {
+			logger.Errorf("Invalid --since value: %v", err)
+			process.Exit(1)
+		}
+		filter := request_log.Filter{Domains: domains, Statuses: statuses, Since: since}
+
+		if errorLogsFlag {
+			printErrorLogs(appNames, filter)
+		} else {
+			printAccessLogs(appNames, filter)
+		}
+
+		if followLogsFlag {
+			followLogs(appNames, filter)
+		}
+	},
+}
+
+// Returns apps whose logs should be shown and optionally the domains to filter by
+func resolveLogsTarget(args []string) ([]string, []string) {
+	novusState := novus.GetState()
+
+	if len(args) == 0 {
+		// Use the app configured in the current directory
+		if conf, exists := config.LoadFile(); exists {
+			if _, found := novusState.Apps[conf.AppName]; !found {
+				logger.Errorf("App \"%s\" is not running, run `novus serve` first", conf.AppName)
+				process.Exit(1)
+			}
+			return []string{conf.AppName}, []string{}
+		}
+
+		// Otherwise show logs of all apps
+		appNames := slices.DeleteFunc(maputils.MapKeys(novusState.Apps), func(appName string) bool {
+			return appName == novus.NovusInternalAppName
+		})
+		slices.Sort(appNames)
+		return appNames, []string{}
+	}
+
+	target := args[0]
+	if _, found := novusState.Apps[target]; found && target != novus.NovusInternalAppName {
+		return []string{target}, []string{}
+	}
+
+	for appName, appState := range novusState.Apps {
+		for _, route := range appState.Routes {
+			if route.Domain == target {
+				return []string{appName}, []string{target}
+			}
+		}
+	}
+
+	logger.Errorf("App or domain \"%s\" does not exist", target)
+	process.Exit(1)
+	return nil, nil
+}
+
+func printAccessLogs(appNames []string, filter request_log.Filter) {
+	entries := []request_log.Entry{}
+	for _, appName := range appNames {
+		for _, entry := range request_log.ReadEntries(appName) {
+			if filter.Matches(entry) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	// Logs from multiple apps are merged by time
+	slices.SortStableFunc(entries, func(a, b request_log.Entry) int { return a.Time.Compare(b.Time) })
+
+	if len(entries) == 0 && !followLogsFlag {
+		logger.Infof("No requests found")
+		return
+	}
+
+	for _, entry := range entries {
+		printAccessLogEntry(entry)
+	}
+}
+
+func printErrorLogs(appNames []string, filter request_log.Filter) {
+	found := false
+	for _, appName := range appNames {
+		for _, line := range request_log.ReadLines(paths.AppErrorLogFilePath(appName)) {
+			if errorLineMatches(line, filter) {
+				printErrorLogLine(line)
+				found = true
+			}
+		}
+	}
+
+	if !found && !followLogsFlag {
+		logger.Infof("No errors found")
+	}
+}
+
+func followLogs(appNames []string, filter request_log.Filter) {
+	var mu sync.Mutex
+	stop := make(chan struct{})
+
+	for _, appName := range appNames {
+		if errorLogsFlag {
+			go request_log.FollowLines(paths.AppErrorLogFilePath(appName), stop, func(line string) {
+				if errorLineMatches(line, filter) {
+					mu.Lock()
+					defer mu.Unlock()
+					printErrorLogLine(line)
+				}
+			})
+		} else {
+			go request_log.FollowLines(paths.AppAccessLogFilePath(appName), stop, func(line string) {
+				entry, ok := request_log.ParseLine(line)
+				if ok && filter.Matches(entry) {
+					mu.Lock()
+					defer mu.Unlock()
+					printAccessLogEntry(entry)
+				}
+			})
+		}
+	}
+
+	// Keep following until interrupted
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+	close(stop)
+}
+
+func printAccessLogEntry(entry request_log.Entry) {
+	if jsonLogsFlag {
+		line, _ := json.Marshal(entry)
+		fmt.Println(string(line))
+		return
+	}
+
+	fmt.Printf(
+		"%s%s%s  %s%d%s  %-6s %s%s%s%s  %s%s%s\n",
+		logger.GRAY, entry.Time.Local().Format("2006-01-02 15:04:05"), logger.RESET,
+		statusColor(entry.Status), entry.Status, logger.RESET,
+		entry.Method,
+		logger.CYAN, entry.Host, logger.RESET, entry.URI,
+		logger.GRAY, formatRequestDuration(entry.Duration), logger.RESET,
+	)
+}
+
+func printErrorLogLine(line string) {
+	if jsonLogsFlag {
+		fmt.Println(line)
+		return
+	}
+
+	color := logger.YELLOW
+	if strings.Contains(line, "[error]") || strings.Contains(line, "[crit]") || strings.Contains(line, "[alert]") || strings.Contains(line, "[emerg]") {
+		color = logger.RED
+	}
+	fmt.Println(color + line + logger.RESET)
+}
+
+// Error log lines are not structured, so only the time and domain filters can be applied
+func errorLineMatches(line string, filter request_log.Filter) bool {
+	if len(filter.Domains) > 0 && !slices.ContainsFunc(filter.Domains, func(domain string) bool {
+		return strings.Contains(line, fmt.Sprintf("host: \"%s\"", domain))
+	}) {
+		return false
+	}
+
+	if !filter.Since.IsZero() && len(line) >= len(nginxErrorLogTimeFormat) {
+		loggedAt, err := time.ParseInLocation(nginxErrorLogTimeFormat, line[:len(nginxErrorLogTimeFormat)], time.Local)
+		if err == nil && loggedAt.Before(filter.Since) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func statusColor(status int) string {
+	switch {
+	case status >= 500:
+		return logger.RED
+	case status >= 400:
+		return logger.YELLOW
+	case status >= 300:
+		return logger.CYAN
+	default:
+		return logger.GREEN
+	}
+}
+
+func formatRequestDuration(seconds float64) string {
+	duration := time.Duration(seconds * float64(time.Second))
+	if duration < time.Second {
+		return fmt.Sprintf("%dms", duration.Milliseconds())
+	}
+	return fmt.Sprintf("%.2fs", duration.Seconds())
+}
+
+func init() {
+	logsCmd.Flags().BoolVarP(&followLogsFlag, "follow", "f", false, "keep printing new requests as they come in")
+	logsCmd.Flags().StringVar(&statusLogsFlag, "status", "", "only show responses with the given status codes, e.g. 5xx or 404,500")
+	logsCmd.Flags().StringVar(&sinceLogsFlag, "since", "", "only show requests from the given time period, e.g. 10m or 2h")
+	logsCmd.Flags().BoolVar(&jsonLogsFlag, "json", false, "print the log entries as JSON")
+	logsCmd.Flags().BoolVar(&errorLogsFlag, "errors", false, "show the Nginx error log instead of requests")
+	rootCmd.AddCommand(logsCmd)
+}
@@ -3,24 +3,29 @@ package cmd
 import (
 	"os"
 
-	"github.com/jozefcipa/novus/internal/dnsmasq"
+	"github.com/jozefcipa/novus/internal/dns_manager"
 	"github.com/jozefcipa/novus/internal/domain_cleanup_manager"
+	"github.com/jozefcipa/novus/internal/dry_run"
+	"github.com/jozefcipa/novus/internal/journal"
 	"github.com/jozefcipa/novus/internal/logger"
-	"github.com/jozefcipa/novus/internal/nginx"
 	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/proxy_manager"
+	"github.com/jozefcipa/novus/internal/transaction"
 	"github.com/jozefcipa/novus/internal/tui"
 	"github.com/spf13/cobra"
 )
 
 var pauseCmd = &cobra.Command{
-	Use:   "pause [app-name]",
-	Short: "Pause routing for [app-name]",
-	Long:  "Pause existing app in Novus so the routing will stop. Run `novus resume [app-name]` to start routing again.",
+	Use:         "pause [app-name]",
+	Short:       "Pause routing for [app-name]",
+	Long:        "Pause existing app in Novus so the routing will stop. Run `novus resume [app-name]` to start routing again.",
+	Annotations: lockState,
 	Run: func(cmd *cobra.Command, args []string) {
 		appName, appState := tui.ParseAppFromArgs(args, "pause")
 		if appState == nil {
 			logger.Errorf("App \"%s\" does not exist", appName)
-			os.Exit(1)
+			process.Exit(1)
 		}
 
 		if appState.Status == novus.APP_PAUSED {
@@ -29,26 +34,47 @@ var pauseCmd = &cobra.Command{
 			os.Exit(0)
 		}
 
-		// Delete all routes
-		domain_cleanup_manager.RemoveDomains(appState.Routes, appName, novus.GetState())
-
-		// Remove NGINX configuration
-		nginx.RemoveConfiguration(appName)
-
-		// Mark app as paused so it won't be routed
-		appState.Status = novus.APP_PAUSED
+		before := journal.Snapshot(appState)
+		pauseApp(appName, appState)
 
 		// Restart services
-		nginx.Restart()
-		dnsmasq.Restart()
+		proxy_manager.Reload()
+		dns_manager.Restart()
+
+		if dry_run.Enabled {
+			dry_run.PrintPlan()
+			return
+		}
 
 		tui.PrintRoutingTable(*novus.GetState())
 
 		// Save state to file
 		novus.SaveState()
+
+		// All changes have been applied successfully
+		transaction.Commit()
+
+		journal.RecordAppChange("pause", args, appName, before, journal.Snapshot(appState))
 	},
 }
 
+// Removes the routes of the app from SSL, Nginx and DNS configuration and marks the app as paused.
+// Services have to be restarted by the caller.
+func pauseApp(appName string, appState *novus.AppState) {
+	// Track all files that will be modified, so they can be restored if anything fails
+	transaction.Begin()
+
+	// Delete all routes
+	domain_cleanup_manager.RemoveDomains(appState.Routes, appName, novus.GetState())
+
+	// Remove NGINX configuration
+	proxy_manager.RemoveConfiguration(appName)
+
+	// Mark app as paused so it won't be routed
+	appState.Status = novus.APP_PAUSED
+}
+
 func init() {
+	pauseCmd.Flags().BoolVar(&dry_run.Enabled, "dry-run", false, "show what would be changed without applying anything")
 	rootCmd.AddCommand(pauseCmd)
 }
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/proxy_server"
+	"github.com/spf13/cobra"
+)
+
+// The built-in reverse proxy, enabled by `proxy: builtin` in ~/.novus/settings.yml.
+// It's started in the background by `novus serve` and `novus start`, so it's not meant to be called directly.
+var proxyCmd = &cobra.Command{
+	Use:    "proxy",
+	Short:  "Manage the built-in Novus proxy",
+	Hidden: true,
+}
+
+// Runs the proxy in the foreground, this is what the background process executes
+var proxyRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the built-in proxy in the foreground",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := proxy_server.Serve(); err != nil {
+			logger.Errorf("Novus proxy failed: %v", err)
+			process.Exit(1)
+		}
+	},
+}
+
+func init() {
+	proxyCmd.AddCommand(proxyRunCmd)
+	rootCmd.AddCommand(proxyCmd)
+}
@@ -5,41 +5,66 @@ import (
 	"os"
 	"slices"
 
+	"github.com/jozefcipa/novus/internal/config"
 	"github.com/jozefcipa/novus/internal/config_manager"
-	"github.com/jozefcipa/novus/internal/dnsmasq"
+	"github.com/jozefcipa/novus/internal/dns_manager"
 	"github.com/jozefcipa/novus/internal/domain_cleanup_manager"
+	"github.com/jozefcipa/novus/internal/dry_run"
+	"github.com/jozefcipa/novus/internal/fs"
+	"github.com/jozefcipa/novus/internal/journal"
 	"github.com/jozefcipa/novus/internal/logger"
-	"github.com/jozefcipa/novus/internal/nginx"
 	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/proxy_manager"
 	"github.com/jozefcipa/novus/internal/sharedtypes"
 	"github.com/jozefcipa/novus/internal/ssl_manager"
+	"github.com/jozefcipa/novus/internal/transaction"
 	"github.com/jozefcipa/novus/internal/tui"
 	"github.com/spf13/cobra"
 )
 
+var skipConfirmationFlag bool
+
 var removeCmd = &cobra.Command{
-	Use:   "remove [app-name]",
-	Short: "Remove routing configuration for [app-name]",
-	Long:  "Remove all domains registered in the configuration for the given app",
+	Use:         "remove [app-name]",
+	Short:       "Remove routing configuration for [app-name]",
+	Long:        "Remove all domains registered in the configuration for the given app",
+	Annotations: lockState,
 	Run: func(cmd *cobra.Command, args []string) {
-		_, appState := tui.ParseAppFromArgs(args, "remove")
+		name, appState := tui.ParseAppFromArgs(args, "remove")
+		novusState := novus.GetState()
 
+		// If there's no such app, check if the domain exists
+		var conf config.NovusConfig
+		idx := -1
 		if appState == nil {
-			// Deleting domain
-			domain := args[0]
-			novusState := novus.GetState()
 			// Get global app configuration
-			conf := config_manager.LoadConfigurationFromState(novus.GlobalAppName, *novusState)
+			conf = config_manager.LoadConfigurationFromState(novus.GlobalAppName, *novusState)
 
-			// Check if the domain exists
-			idx := slices.IndexFunc(conf.Routes, func(route sharedtypes.Route) bool { return route.Domain == domain })
+			idx = slices.IndexFunc(conf.Routes, func(route sharedtypes.Route) bool { return route.Domain == name })
 			if idx == -1 {
-				logger.Errorf("Domain or app \"%s\" does not exist", domain)
-				os.Exit(1)
+				logger.Errorf("Domain or app \"%s\" does not exist", name)
+				process.Exit(1)
 			}
+		}
+
+		// Removing a domain changes the global app
+		changedAppName := name
+		if appState == nil {
+			changedAppName = novus.GlobalAppName
+		}
+		before := journal.SnapshotApp(changedAppName)
+
+		// Track all files that will be modified, so they can be restored if anything fails
+		transaction.Begin()
+
+		if appState == nil {
+			// Deleting domain
+			domain := name
 
 			// Confirm deleting
-			if !tui.Confirm(fmt.Sprintf("Do you want to remove \"%s\" domain?", domain)) {
+			if !dry_run.Enabled && !skipConfirmationFlag && !tui.Confirm(fmt.Sprintf("Do you want to remove \"%s\" domain?", domain)) {
 				os.Exit(0)
 			}
 
@@ -54,21 +79,21 @@ var removeCmd = &cobra.Command{
 
 			// Update NGINX configuration
 			appState, _ := novus.GetAppState(novus.GlobalAppName)
-			nginx.Configure(conf, domainCerts, appState)
+			proxy_manager.Configure(conf, domainCerts, appState)
 
 			logger.Checkf("Domain [%s] has been removed", domain)
 		} else {
 			// Deleting app
-			appName := args[0]
-			if !tui.Confirm(fmt.Sprintf("Do you want to remove \"%s\" configuration?", appName)) {
+			appName := name
+			if !dry_run.Enabled && !skipConfirmationFlag && !tui.Confirm(fmt.Sprintf("Do you want to remove \"%s\" configuration?", appName)) {
 				os.Exit(0)
 			}
 
 			// Delete all routes
-			domain_cleanup_manager.RemoveDomains(appState.Routes, appName, novus.GetState())
+			domain_cleanup_manager.RemoveDomains(appState.Routes, appName, novusState)
 
 			// Remove NGINX configuration
-			nginx.RemoveConfiguration(appName)
+			proxy_manager.RemoveConfiguration(appName)
 
 			// Remove app from Novus state
 			novus.RemoveAppState(appName)
@@ -77,16 +102,33 @@ var removeCmd = &cobra.Command{
 		}
 
 		// Restart services
-		nginx.Restart()
-		dnsmasq.Restart()
+		proxy_manager.Reload()
+		dns_manager.Restart()
+
+		if dry_run.Enabled {
+			dry_run.PrintPlan()
+			return
+		}
 
 		tui.PrintRoutingTable(*novus.GetState())
 
 		// Save state to file
 		novus.SaveState()
+
+		// All changes have been applied successfully
+		transaction.Commit()
+
+		journal.RecordAppChange("remove", args, changedAppName, before, journal.SnapshotApp(changedAppName))
+
+		// Request logs of a removed app are no longer needed
+		if appState != nil {
+			fs.DeleteDir(paths.AppLogsDir(name))
+		}
 	},
 }
 
 func init() {
+	removeCmd.Flags().BoolVar(&dry_run.Enabled, "dry-run", false, "show what would be changed without applying anything")
+	removeCmd.Flags().BoolVarP(&skipConfirmationFlag, "yes", "y", false, "remove without asking for confirmation")
 	rootCmd.AddCommand(removeCmd)
 }
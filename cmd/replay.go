@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/replay"
+	"github.com/spf13/cobra"
+)
+
+var replayToFlag string
+var replayInsecureFlag bool
+var replayJSONFlag bool
+var replayTimeoutFlag time.Duration
+
+var replayCmd = &cobra.Command{
+	Use:   "replay [file]",
+	Short: "Send recorded requests again and compare the responses",
+	Long: `Send requests recorded by "novus capture" (HAR file) or from an access log (see "novus logs --json") again,
+with their original methods, headers and bodies, and show how the status codes and latencies differ.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		requests, err := replay.LoadFile(args[0])
+		if err != nil {
+			logger.Errorf("Failed to read recorded requests from %s: %v", args[0], err)
+			process.Exit(1)
+		}
+		if len(requests) == 0 {
+			logger.Warnf("No requests found in %s", args[0])
+			return
+		}
+
+		if replayToFlag != "" && !domainExists(replayToFlag) {
+			logger.Warnf("Domain \"%s\" is not registered in Novus", replayToFlag)
+		}
+
+		client := replay.NewClient(replayInsecureFlag, replayTimeoutFlag)
+		results := []replay.Result{}
+		for _, request := range requests {
+			result := replay.Send(client, request, replayToFlag)
+			results = append(results, result)
+
+			if !replayJSONFlag {
+				printReplayResult(result)
+			}
+		}
+
+		if replayJSONFlag {
+			out, _ := replay.MarshalResults(results)
+			fmt.Println(string(out))
+			return
+		}
+
+		printReplaySummary(results)
+	},
+}
+
+func printReplayResult(result replay.Result) {
+	url := result.URL
+	if url == "" {
+		url = result.Request.URL
+	}
+
+	if result.Err != nil {
+		fmt.Printf("%s✘%s %-6s %s\n   %s%v%s\n", logger.RED, logger.RESET, result.Request.Method, url, logger.RED, result.Err, logger.RESET)
+		return
+	}
+
+	mark := logger.GREEN + "✔" + logger.RESET
+	status := fmt.Sprintf("%s%d%s", statusColor(result.Status), result.Status, logger.RESET)
+	if result.StatusChanged() {
+		mark = logger.YELLOW + "≠" + logger.RESET
+		status = fmt.Sprintf("%s%d%s → %s", statusColor(result.Request.OriginalStatus), result.Request.OriginalStatus, logger.RESET, status)
+	}
+
+	note := ""
+	if result.Request.BodyTruncated {
+		note = logger.YELLOW + "  (request body was truncated when recorded)" + logger.RESET
+	}
+
+	fmt.Printf(
+		"%s %-6s %s  %s  %s%s%s%s\n",
+		mark, result.Request.Method, url, status,
+		logger.GRAY, formatLatencyDiff(result.Request.OriginalDuration, result.Duration), logger.RESET,
+		note,
+	)
+}
+
+func printReplaySummary(results []replay.Result) {
+	changed := 0
+	failed := 0
+	var originalTotal, total time.Duration
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			continue
+		}
+		if result.StatusChanged() {
+			changed++
+		}
+		originalTotal += result.Request.OriginalDuration
+		total += result.Duration
+	}
+
+	fmt.Println()
+	succeeded := len(results) - failed
+	if succeeded > 0 {
+		logger.Infof(
+			"Average latency: %s",
+			formatLatencyDiff(originalTotal/time.Duration(succeeded), total/time.Duration(succeeded)),
+		)
+	}
+
+	if changed == 0 && failed == 0 {
+		logger.Checkf("%d requests replayed, all responded with the original status", len(results))
+		return
+	}
+	logger.Warnf("%d requests replayed, %d responded with a different status, %d failed", len(results), changed, failed)
+}
+
+func formatLatencyDiff(original time.Duration, current time.Duration) string {
+	diff := current - original
+	sign := "+"
+	if diff < 0 {
+		sign = "-"
+		diff = -diff
+	}
+
+	return fmt.Sprintf("%s → %s (%s%s)", formatRequestDuration(original.Seconds()), formatRequestDuration(current.Seconds()), sign, formatRequestDuration(diff.Seconds()))
+}
+
+func domainExists(domain string) bool {
+	for _, appState := range novus.GetState().Apps {
+		for _, route := range appState.Routes {
+			if route.Domain == domain {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func init() {
+	replayCmd.Flags().StringVar(&replayToFlag, "to", "", "send the requests to this domain instead of the recorded one")
+	replayCmd.Flags().BoolVarP(&replayInsecureFlag, "insecure", "k", false, "skip TLS certificate verification")
+	replayCmd.Flags().BoolVar(&replayJSONFlag, "json", false, "print the results as JSON")
+	replayCmd.Flags().DurationVar(&replayTimeoutFlag, "timeout", 30*time.Second, "timeout for each request")
+	rootCmd.AddCommand(replayCmd)
+}
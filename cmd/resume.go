@@ -4,28 +4,33 @@ import (
 	"os"
 	"slices"
 
+	"github.com/jozefcipa/novus/internal/agent"
+	"github.com/jozefcipa/novus/internal/ca"
 	"github.com/jozefcipa/novus/internal/config_manager"
 	"github.com/jozefcipa/novus/internal/dns_manager"
-	"github.com/jozefcipa/novus/internal/dnsmasq"
+	"github.com/jozefcipa/novus/internal/dry_run"
+	"github.com/jozefcipa/novus/internal/journal"
 	"github.com/jozefcipa/novus/internal/logger"
-	"github.com/jozefcipa/novus/internal/mkcert"
-	"github.com/jozefcipa/novus/internal/nginx"
 	"github.com/jozefcipa/novus/internal/novus"
 	"github.com/jozefcipa/novus/internal/ports"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/proxy_manager"
 	"github.com/jozefcipa/novus/internal/ssl_manager"
+	"github.com/jozefcipa/novus/internal/transaction"
 	"github.com/jozefcipa/novus/internal/tui"
 	"github.com/spf13/cobra"
 )
 
 var resumeCmd = &cobra.Command{
-	Use:   "resume [app-name]",
-	Short: "Resume routing for [app-name]",
-	Long:  "Resume paused app in Novus so the routing will start again. Similar to running `novus serve` but the app has to be registered already",
+	Use:         "resume [app-name]",
+	Short:       "Resume routing for [app-name]",
+	Long:        "Resume paused app in Novus so the routing will start again. Similar to running `novus serve` but the app has to be registered already",
+	Annotations: lockState,
 	Run: func(cmd *cobra.Command, args []string) {
 		appName, appState := tui.ParseAppFromArgs(args, "resume")
 		if appState == nil {
 			logger.Errorf("App \"%s\" does not exist", appName)
-			os.Exit(1)
+			process.Exit(1)
 		}
 
 		if appState.Status == novus.APP_ACTIVE {
@@ -35,41 +40,67 @@ var resumeCmd = &cobra.Command{
 		}
 
 		novusState := novus.GetState()
-
-		// Load config from state
-		conf := config_manager.LoadConfigurationFromState(appName, *novusState)
-		config_manager.ValidateConfigDomainsUniqueness(conf, *novusState)
-
-		// Check if ports are available
-		portsUsage := ports.CheckPortsUsage(slices.Concat(nginx.Ports, []string{dns_manager.GetDNSPort(novusState)})...)
-		nginx.CheckPortsAvailability(portsUsage)
-		dns_manager.EnsurePort(portsUsage, novusState)
-
-		// Configure SSL
-		mkcert.Configure()
-		domainCerts, _ := ssl_manager.EnsureSSLCertificates(conf, novusState, appName)
-
-		// Configure Nginx
-		nginx.Configure(conf, domainCerts, appState)
-
-		// Configure DNS
-		dns_manager.Configure(conf, novusState)
+		before := journal.Snapshot(appState)
+		resumeApp(appName, appState)
 
 		// Restart services
-		nginx.Restart()
-		dnsmasq.Restart()
+		proxy_manager.Reload()
+		dns_manager.Restart()
 
-		// If app has been paused, make sure to set it to ACTIVE
-		appState.Status = novus.APP_ACTIVE
+		// Make sure the control API is available
+		agent.EnsureRunning()
+
+		if dry_run.Enabled {
+			dry_run.PrintPlan()
+			return
+		}
 
 		// Everything's set, start routing
 		tui.PrintRoutingTable(*novusState)
 
 		// Save application state
 		novus.SaveState()
+
+		// All changes have been applied successfully
+		transaction.Commit()
+
+		journal.RecordAppChange("resume", args, appName, before, journal.Snapshot(appState))
 	},
 }
 
+// Configures SSL, Nginx and DNS for the routes stored in the app state and marks the app as active.
+// Services have to be restarted by the caller.
+func resumeApp(appName string, appState *novus.AppState) {
+	novusState := novus.GetState()
+
+	// Load config from state
+	conf := config_manager.LoadConfigurationFromState(appName, *novusState)
+	config_manager.ValidateConfigDomainsUniqueness(conf, *novusState)
+
+	// Track all files that will be modified, so they can be restored if anything fails
+	// (EnsurePort might already move DNSMasq to another port)
+	transaction.Begin()
+
+	// Check if ports are available
+	portsUsage := ports.CheckPortsUsage(slices.Concat(proxy_manager.Ports, []string{dns_manager.GetDNSPort(novusState)})...)
+	proxy_manager.CheckPortsAvailability(portsUsage)
+	dns_manager.EnsurePort(portsUsage, novusState)
+
+	// Configure SSL
+	ca.Configure()
+	domainCerts, _ := ssl_manager.EnsureSSLCertificates(conf, novusState, appName)
+
+	// Configure Nginx
+	proxy_manager.Configure(conf, domainCerts, appState)
+
+	// Configure DNS
+	dns_manager.Configure(conf, novusState)
+
+	// If app has been paused, make sure to set it to ACTIVE
+	appState.Status = novus.APP_ACTIVE
+}
+
 func init() {
+	resumeCmd.Flags().BoolVar(&dry_run.Enabled, "dry-run", false, "show what would be changed without applying anything")
 	rootCmd.AddCommand(resumeCmd)
 }
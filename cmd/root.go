@@ -13,11 +13,20 @@ import (
 	"github.com/jozefcipa/novus/internal/logger"
 	"github.com/jozefcipa/novus/internal/novus"
 	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/process"
 	"github.com/jozefcipa/novus/internal/sharedtypes"
 	"github.com/jozefcipa/novus/internal/tld"
 	"github.com/spf13/cobra"
 )
 
+// Commands with this annotation hold the state lock while running, so they can't be run concurrently
+const lockStateAnnotation = "lockState"
+
+var lockState = map[string]string{lockStateAnnotation: "true"}
+
+// Commands with this annotation load the state by themselves (e.g. to be able to recover a corrupted state file)
+const skipStateLoadAnnotation = "skipStateLoad"
+
 var rootCmd = &cobra.Command{
 	// Version is passed down from the main.go,
 	// this is only a placeholder to enable the --version flag
@@ -29,14 +38,24 @@ var rootCmd = &cobra.Command{
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		paths.Resolve()
 		tld.LoadExistingTLDsFile()
+
+		// Commands changing the state must wait until other commands finish
+		if cmd.Annotations[lockStateAnnotation] == "true" {
+			novus.Lock()
+		}
+		if cmd.Annotations[skipStateLoadAnnotation] == "true" {
+			return
+		}
 		novusState := novus.GetState()
 
 		ctx := cmd.Context().Value(sharedtypes.CommandContext{}).(sharedtypes.CommandContext)
 
-		if novusState.Version != ctx.Version {
-			// logger.Infof("🚀 New Novus version detected [%s]. Updating state...", ctx.Version)
-			// Here we can update the configuration/state in the future if needed after a new version is released
-
+		// The state has been migrated to the current schema when loaded (see novus/migrations.go), so just save it
+		if novusState.Version != ctx.Version || novus.IsMigrated() {
+			if !novus.IsLocked() {
+				novus.Lock()
+				defer novus.Unlock()
+			}
 			novusState.Version = ctx.Version
 			novus.SaveState()
 		}
@@ -97,10 +116,11 @@ func Execute(version string, buildDate string) {
 
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		process.Exit(1)
 	}
 }
 
 func init() {
 	rootCmd.PersistentFlags().BoolVar(&logger.DebugEnabled, "debug", false, "include debug logs")
+	rootCmd.PersistentFlags().DurationVar(&novus.LockTimeout, "lock-timeout", novus.LockTimeout, "how long to wait for other running Novus commands to finish")
 }
@@ -1,39 +1,45 @@
 package cmd
 
 import (
-	"os"
+	"fmt"
 	"slices"
 	"strings"
 
+	"github.com/jozefcipa/novus/internal/agent"
+	"github.com/jozefcipa/novus/internal/ca"
 	"github.com/jozefcipa/novus/internal/config"
 	"github.com/jozefcipa/novus/internal/config_manager"
 	"github.com/jozefcipa/novus/internal/diff_manager"
 	"github.com/jozefcipa/novus/internal/dns_manager"
-	"github.com/jozefcipa/novus/internal/dnsmasq"
 	"github.com/jozefcipa/novus/internal/domain_cleanup_manager"
-	"github.com/jozefcipa/novus/internal/homebrew"
+	"github.com/jozefcipa/novus/internal/dry_run"
+	"github.com/jozefcipa/novus/internal/installer"
+	"github.com/jozefcipa/novus/internal/journal"
 	"github.com/jozefcipa/novus/internal/logger"
-	"github.com/jozefcipa/novus/internal/mkcert"
-	"github.com/jozefcipa/novus/internal/nginx"
 	"github.com/jozefcipa/novus/internal/novus"
 	"github.com/jozefcipa/novus/internal/paths"
 	"github.com/jozefcipa/novus/internal/ports"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/proxy_manager"
 	"github.com/jozefcipa/novus/internal/sharedtypes"
 	"github.com/jozefcipa/novus/internal/ssl_manager"
+	"github.com/jozefcipa/novus/internal/transaction"
 	"github.com/jozefcipa/novus/internal/tui"
 
 	"github.com/spf13/cobra"
 )
 
 var serveCmd = &cobra.Command{
-	Use:   "serve [domain?] [upstream?]",
-	Short: "Configure URLs and start routing",
-	Long:  `Install Nginx, DNSMasq and mkcert and automatically expose HTTPs URLs for the endpoints defined in the config.`,
+	Use:         "serve [domain?] [upstream?]",
+	Short:       "Configure URLs and start routing",
+	Long:        `Install Nginx and DNSMasq and automatically expose HTTPs URLs for the endpoints defined in the config.`,
+	Annotations: lockState,
 	Run: func(cmd *cobra.Command, args []string) {
 		// If the binaries are missing, exit here, user needs to run `novus init` first
-		if err := homebrew.CheckIfRequiredBinariesInstalled(); err != nil {
+		if err := installer.CheckRequiredBinaries(); err != nil {
+			logger.Errorf(err.Error())
 			logger.Hintf("Run \"novus init\" first to initialize Novus.")
-			os.Exit(1)
+			process.Exit(1)
 		}
 
 		var conf config.NovusConfig
@@ -62,7 +68,7 @@ var serveCmd = &cobra.Command{
 			// Validate input
 			if errors := config_manager.ValidateConfig(conf, config_manager.ValidationErrorsGlobalAppInput); len(errors) > 0 {
 				logger.Errorf("Invalid configuration:\n   %s", strings.Join(errors, "\n   "))
-				os.Exit(1)
+				process.Exit(1)
 			}
 		} else {
 			// Otherwise, load configuration file
@@ -71,12 +77,13 @@ var serveCmd = &cobra.Command{
 			if !exists {
 				logger.Warnf("Novus is not initialized in this directory (%s file does not exist).", config.ConfigFileName)
 				logger.Hintf("Run \"novus init\" to create a configuration file.")
-				os.Exit(1)
+				process.Exit(1)
 			}
 		}
 
 		config_manager.ValidateConfigDomainsUniqueness(conf, *novusState)
 		appName := config.AppName()
+		before := journal.SnapshotApp(appName)
 
 		// Load application state
 		appState, appStateExists := novus.GetAppState(appName)
@@ -85,7 +92,10 @@ var serveCmd = &cobra.Command{
 		}
 
 		// Compare state and current config to detect changes
-		addedRoutes, deletedRoutes := diff_manager.DetectConfigDiff(conf, *appState)
+		addedRoutes, deletedRoutes, modifiedRoutes := diff_manager.DetectConfigDiff(conf, *appState)
+
+		// Track all files that will be modified, so they can be restored if anything fails
+		transaction.Begin()
 
 		// Remove domains that are no longer in config
 		if len(deletedRoutes) > 0 {
@@ -103,53 +113,98 @@ var serveCmd = &cobra.Command{
 			}
 		}
 
+		if len(modifiedRoutes) > 0 {
+			if len(modifiedRoutes) == 1 {
+				logger.Successf("Found a modified domain [%s]", modifiedRoutes[0])
+			} else {
+				logger.Successf("Found %d modified domains:", len(modifiedRoutes))
+				for _, modifiedRoute := range modifiedRoutes {
+					logger.Infof("   - %s", modifiedRoute)
+				}
+			}
+		}
+
+		if dry_run.Enabled {
+			recordRouteChanges(addedRoutes, deletedRoutes, modifiedRoutes)
+		}
+
 		// Check if ports are available
-		portsUsage := ports.CheckPortsUsage(slices.Concat(nginx.Ports, []string{dns_manager.GetDNSPort(novusState)})...)
-		nginx.CheckPortsAvailability(portsUsage)
+		portsUsage := ports.CheckPortsUsage(slices.Concat(proxy_manager.Ports, []string{dns_manager.GetDNSPort(novusState)})...)
+		proxy_manager.CheckPortsAvailability(portsUsage)
 		dns_manager.EnsurePort(portsUsage, novusState)
 
 		// Configure SSL
-		mkcert.Configure()
+		ca.Configure()
 		domainCerts, hasNewCerts := ssl_manager.EnsureSSLCertificates(conf, novusState, appName)
 
-		// Configure Nginx
-		nginxConfigUpdated := nginx.Configure(conf, domainCerts, appState)
+		// Configure proxy
+		proxyConfigUpdated := proxy_manager.Configure(conf, domainCerts, appState)
 
 		// Configure DNS
 		dnsUpdated := dns_manager.Configure(conf, novusState)
 
 		// Restart services
-		// Nginx
-		nginxLoader := logger.Loadingf("Checking Nginx status")
-		isNginxRunning := nginx.IsRunning()
-		if nginxConfigUpdated || hasNewCerts || !isNginxRunning {
-			nginxLoader.Done()
-			nginx.Restart()
+		// Proxy
+		proxyLoader := logger.Loadingf("Checking %s status", proxy_manager.ServerName())
+		isProxyRunning := proxy_manager.IsRunning()
+		// Route changes (including upstream or CORS modifications) only affect the proxy, DNS restarts just for new TLDs
+		routesChanged := diff_manager.RoutesChanged(addedRoutes, deletedRoutes, modifiedRoutes)
+		if routesChanged || proxyConfigUpdated || hasNewCerts || !isProxyRunning {
+			proxyLoader.Done()
+			proxy_manager.Reload()
 		} else {
-			nginxLoader.Checkf("Nginx running")
+			proxyLoader.Checkf("%s running", proxy_manager.ServerName())
 		}
 
-		// DNSMasq
-		dnsmasqLoader := logger.Loadingf("Checking DNSMasq status")
-		isDNSMasqRunning := dnsmasq.IsRunning()
-		if dnsUpdated || !isDNSMasqRunning {
-			dnsmasqLoader.Done()
-			dnsmasq.Restart()
+		// DNS
+		dnsLoader := logger.Loadingf("Checking %s status", dns_manager.ServerName())
+		isDNSRunning := dns_manager.IsRunning()
+		if dnsUpdated || !isDNSRunning {
+			dnsLoader.Done()
+			dns_manager.Restart()
 		} else {
-			dnsmasqLoader.Checkf("DNSMasq running")
+			dnsLoader.Checkf("%s running", dns_manager.ServerName())
 		}
 
+		// Make sure the control API is available
+		agent.EnsureRunning()
+
 		// If app has been paused, make sure to set it to ACTIVE
 		appState.Status = novus.APP_ACTIVE
 
+		if dry_run.Enabled {
+			dry_run.PrintPlan()
+			return
+		}
+
 		// Everything's set, start routing
 		tui.PrintRoutingTable(*novusState)
 
 		// Save application state
 		novus.SaveState()
+
+		// All changes have been applied successfully
+		transaction.Commit()
+
+		proxy_manager.RotateLogs()
+
+		journal.RecordAppChange("serve", args, appName, before, journal.Snapshot(appState))
 	},
 }
 
+func recordRouteChanges(addedRoutes []sharedtypes.Route, deletedRoutes []sharedtypes.Route, modifiedRoutes []diff_manager.ModifiedRoute) {
+	for _, route := range addedRoutes {
+		dry_run.Record(dry_run.Action{Type: dry_run.AddRoute, Target: fmt.Sprintf("%s → %s", route.Domain, route.Upstream)})
+	}
+	for _, route := range deletedRoutes {
+		dry_run.Record(dry_run.Action{Type: dry_run.RemoveRoute, Target: route.Domain})
+	}
+	for _, route := range modifiedRoutes {
+		dry_run.Record(dry_run.Action{Type: dry_run.ModifyRoute, Target: route.String()})
+	}
+}
+
 func init() {
+	serveCmd.Flags().BoolVar(&dry_run.Enabled, "dry-run", false, "show what would be changed without applying anything")
 	rootCmd.AddCommand(serveCmd)
 }
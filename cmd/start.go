@@ -1,60 +1,68 @@
 package cmd
 
 import (
-	"os"
 	"slices"
 
+	"github.com/jozefcipa/novus/internal/agent"
 	"github.com/jozefcipa/novus/internal/dns_manager"
-	"github.com/jozefcipa/novus/internal/dnsmasq"
-	"github.com/jozefcipa/novus/internal/homebrew"
+	"github.com/jozefcipa/novus/internal/installer"
 	"github.com/jozefcipa/novus/internal/logger"
-	"github.com/jozefcipa/novus/internal/nginx"
 	"github.com/jozefcipa/novus/internal/novus"
 	"github.com/jozefcipa/novus/internal/ports"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/proxy_manager"
 	"github.com/jozefcipa/novus/internal/tui"
 
 	"github.com/spf13/cobra"
 )
 
 var startCmd = &cobra.Command{
-	Use:   "start",
-	Short: "Start Nginx and DNSMasq services",
-	Long:  `Start Nginx, DNSMasq and start routing URLs.`,
+	Use:         "start",
+	Short:       "Start proxy and DNS services",
+	Long:        `Start Nginx, DNS server (DNSMasq or the built-in one) and start routing URLs.`,
+	Annotations: lockState,
 	Run: func(cmd *cobra.Command, args []string) {
 		// If the binaries are missing, exit here, user needs to run `novus init` first
-		if err := homebrew.CheckIfRequiredBinariesInstalled(); err != nil {
+		if err := installer.CheckRequiredBinaries(); err != nil {
+			logger.Errorf(err.Error())
 			logger.Hintf("Run \"novus init\" first to initialize Novus.")
-			os.Exit(1)
+			process.Exit(1)
 		}
 
 		novusState := novus.GetState()
 
 		// Check if ports are available
-		portsUsage := ports.CheckPortsUsage(slices.Concat(nginx.Ports, []string{dns_manager.GetDNSPort(novusState)})...)
-		nginx.CheckPortsAvailability(portsUsage)
+		portsUsage := ports.CheckPortsUsage(slices.Concat(proxy_manager.Ports, []string{dns_manager.GetDNSPort(novusState)})...)
+		proxy_manager.CheckPortsAvailability(portsUsage)
 		dns_manager.EnsurePort(portsUsage, novusState)
 
 		// Restart services
-		// Nginx
-		nginxLoader := logger.Loadingf("Checking Nginx status")
-		isNginxRunning := nginx.IsRunning()
-		if !isNginxRunning {
-			nginxLoader.Done()
-			nginx.Restart()
+		// Proxy
+		proxyLoader := logger.Loadingf("Checking %s status", proxy_manager.ServerName())
+		isProxyRunning := proxy_manager.IsRunning()
+		if !isProxyRunning {
+			proxyLoader.Done()
+			proxy_manager.Restart()
 		} else {
-			nginxLoader.Checkf("Nginx running")
+			proxyLoader.Checkf("%s running", proxy_manager.ServerName())
 		}
 
-		// DNSMasq
-		dnsmasqLoader := logger.Loadingf("Checking DNSMasq status")
-		isDNSMasqRunning := dnsmasq.IsRunning()
-		if !isDNSMasqRunning {
-			dnsmasqLoader.Done()
-			dnsmasq.Restart()
+		// DNS
+		dnsLoader := logger.Loadingf("Checking %s status", dns_manager.ServerName())
+		isDNSRunning := dns_manager.IsRunning()
+		if !isDNSRunning {
+			dnsLoader.Done()
+			dns_manager.Restart()
 		} else {
-			dnsmasqLoader.Checkf("DNSMasq running")
+			dnsLoader.Checkf("%s running", dns_manager.ServerName())
 		}
 
+		// Make sure the control API is available
+		agent.EnsureRunning()
+
+		// Request logs are also rotated by the agent, but it might not be running
+		proxy_manager.RotateLogs()
+
 		// Everything's set, start routing
 		tui.PrintRoutingTable(*novusState)
 	},
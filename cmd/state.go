@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/tui"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var listBackupsFlag bool
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Manage the Novus state file",
+	Long:  "Manage the Novus state file (~/.novus/novus.json) and its backups",
+}
+
+var stateRestoreCmd = &cobra.Command{
+	Use:   "restore [backup?]",
+	Short: "Restore the state file from a backup",
+	Long: `Restore the state file from a backup. Novus keeps the last ` + strconv.Itoa(novus.MaxStateBackups) + ` versions of the state file.
+Without arguments, the newest valid backup is restored. A backup can be selected by its number or file name (see --list).`,
+	Args: cobra.MaximumNArgs(1),
+	Annotations: map[string]string{
+		lockStateAnnotation:     "true",
+		skipStateLoadAnnotation: "true",
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		backups := novus.ListStateBackups()
+		if len(backups) == 0 {
+			logger.Warnf("No backups found in %s", paths.NovusStateBackupsDir)
+			return
+		}
+
+		if listBackupsFlag {
+			printStateBackups(backups)
+			return
+		}
+
+		backup := selectStateBackup(backups, args)
+		if !backup.Valid {
+			logger.Errorf("Backup %s is not a valid state file", filepath.Base(backup.Path))
+			process.Exit(1)
+		}
+
+		if !skipConfirmationFlag && !tui.Confirm(fmt.Sprintf("Do you want to restore the state from %s (%d apps)?", backup.CreatedAt.Format(time.DateTime), backup.AppsCount)) {
+			os.Exit(0)
+		}
+
+		if err := novus.RestoreStateBackup(backup); err != nil {
+			logger.Errorf("Failed to restore backup %s\n   Reason: %v", filepath.Base(backup.Path), err)
+			process.Exit(1)
+		}
+
+		logger.Checkf("State restored from %s", filepath.Base(backup.Path))
+		logger.Hintf("Nginx and DNS configuration hasn't been changed, run \"novus serve\" (or \"novus resume\") for your apps to apply the restored routes.")
+		tui.PrintRoutingTable(*novus.GetState())
+	},
+}
+
+func selectStateBackup(backups []novus.StateBackup, args []string) novus.StateBackup {
+	if len(args) == 0 {
+		idx := slices.IndexFunc(backups, func(backup novus.StateBackup) bool { return backup.Valid })
+		if idx == -1 {
+			logger.Errorf("No valid backup found in %s", paths.NovusStateBackupsDir)
+			process.Exit(1)
+		}
+		return backups[idx]
+	}
+
+	// Select by the number shown in the list
+	if number, err := strconv.Atoi(args[0]); err == nil {
+		if number < 1 || number > len(backups) {
+			logger.Errorf("Backup #%d does not exist", number)
+			process.Exit(1)
+		}
+		return backups[number-1]
+	}
+
+	// Select by the file name
+	idx := slices.IndexFunc(backups, func(backup novus.StateBackup) bool { return filepath.Base(backup.Path) == args[0] })
+	if idx == -1 {
+		logger.Errorf("Backup \"%s\" does not exist", args[0])
+		logger.Hintf("Run \"novus state restore --list\" to see all backups.")
+		process.Exit(1)
+	}
+
+	return backups[idx]
+}
+
+func printStateBackups(backups []novus.StateBackup) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"#", "Created at", "File", "Apps", "Size"})
+	table.SetAutoFormatHeaders(false)
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: true})
+	table.SetCenterSeparator("|")
+
+	for i, backup := range backups {
+		apps := strconv.Itoa(backup.AppsCount)
+		if !backup.Valid {
+			apps = "invalid"
+		}
+
+		table.Append([]string{
+			strconv.Itoa(i + 1),
+			backup.CreatedAt.Format(time.DateTime),
+			filepath.Base(backup.Path),
+			apps,
+			fmt.Sprintf("%.1f kB", float64(backup.Size)/1024),
+		})
+	}
+
+	table.Render()
+}
+
+func init() {
+	stateRestoreCmd.Flags().BoolVar(&listBackupsFlag, "list", false, "list available backups")
+	stateRestoreCmd.Flags().BoolVarP(&skipConfirmationFlag, "yes", "y", false, "restore without asking for confirmation")
+	stateCmd.AddCommand(stateRestoreCmd)
+	rootCmd.AddCommand(stateCmd)
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/metrics"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/request_log"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var sinceStatsFlag string
+var topStatsFlag int
+
+var statsCmd = &cobra.Command{
+	Use:   "stats [app-name|domain]",
+	Short: "Show a summary of the proxied traffic",
+	Long: `Show request counts, status codes and latencies per domain, the most active clients and the slowest endpoints.
+The same metrics are available in the Prometheus format at https://` + novus.NovusInternalDomain + `/metrics (authenticated with the API token, see "novus agent token")`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		appNames, domains := resolveLogsTarget(args)
+
+		since, err := request_log.ParseSince(sinceStatsFlag)
+		if err != nil {
+			logger.Errorf("Invalid --since value: %v", err)
+			process.Exit(1)
+		}
+		filter := request_log.Filter{Domains: domains, Since: since}
+
+		appEntries := map[string][]request_log.Entry{}
+		for _, appName := range appNames {
+			for _, entry := range request_log.ReadEntries(appName) {
+				if filter.Matches(entry) {
+					appEntries[appName] = append(appEntries[appName], entry)
+				}
+			}
+		}
+
+		summary := metrics.Summarize(appEntries)
+		if len(summary.Domains) == 0 {
+			logger.Infof("No requests found")
+			return
+		}
+
+		logger.Infof(
+			"Requests from %s to %s",
+			summary.From.Local().Format("2006-01-02 15:04:05"),
+			summary.To.Local().Format("2006-01-02 15:04:05"),
+		)
+
+		printDomainStats(summary.Domains)
+		printClientStats(summary.Clients[:min(topStatsFlag, len(summary.Clients))])
+		printEndpointStats(summary.Endpoints[:min(topStatsFlag, len(summary.Endpoints))])
+	},
+}
+
+func printDomainStats(domains []metrics.DomainStats) {
+	table := newStatsTable("Domain", "Requests", "2xx", "3xx", "4xx", "5xx", "Upstream errors", "p50", "p95")
+	for _, domain := range domains {
+		table.Append([]string{
+			domain.Domain,
+			fmt.Sprint(domain.Requests),
+			fmt.Sprint(domain.StatusClasses["2xx"]),
+			fmt.Sprint(domain.StatusClasses["3xx"]),
+			fmt.Sprint(domain.StatusClasses["4xx"]),
+			fmt.Sprint(domain.StatusClasses["5xx"]),
+			fmt.Sprint(domain.UpstreamErrors),
+			formatStatsDuration(domain.P50),
+			formatStatsDuration(domain.P95),
+		})
+	}
+
+	fmt.Println()
+	table.Render()
+}
+
+func printClientStats(clients []metrics.ClientStats) {
+	table := newStatsTable("Client", "User agent", "Requests")
+	for _, client := range clients {
+		table.Append([]string{client.RemoteAddr, truncate(client.UserAgent, 60), fmt.Sprint(client.Requests)})
+	}
+
+	fmt.Println()
+	logger.Infof("Most active clients")
+	table.Render()
+}
+
+func printEndpointStats(endpoints []metrics.EndpointStats) {
+	table := newStatsTable("Endpoint", "Requests", "Average", "p95")
+	for _, endpoint := range endpoints {
+		table.Append([]string{
+			fmt.Sprintf("%s %s%s", endpoint.Method, endpoint.Domain, truncate(endpoint.Path, 60)),
+			fmt.Sprint(endpoint.Requests),
+			formatStatsDuration(endpoint.Average),
+			formatStatsDuration(endpoint.P95),
+		})
+	}
+
+	fmt.Println()
+	logger.Infof("Slowest endpoints")
+	table.Render()
+}
+
+func newStatsTable(header ...string) *tablewriter.Table {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(header)
+	table.SetAutoFormatHeaders(false)
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: true})
+	table.SetCenterSeparator("|")
+
+	return table
+}
+
+func formatStatsDuration(duration time.Duration) string {
+	return formatRequestDuration(duration.Seconds())
+}
+
+func truncate(value string, length int) string {
+	if len([]rune(value)) <= length {
+		return value
+	}
+	return string([]rune(value)[:length-1]) + "…"
+}
+
+func init() {
+	statsCmd.Flags().StringVar(&sinceStatsFlag, "since", "", "only include requests from the given time period, e.g. 10m or 2h")
+	statsCmd.Flags().IntVar(&topStatsFlag, "top", 5, "number of clients and endpoints to show")
+	rootCmd.AddCommand(statsCmd)
+}
@@ -1,10 +1,13 @@
 package cmd
 
 import (
-	"github.com/jozefcipa/novus/internal/dnsmasq"
+	"github.com/jozefcipa/novus/internal/agent"
+	"github.com/jozefcipa/novus/internal/dns_manager"
 	"github.com/jozefcipa/novus/internal/logger"
-	"github.com/jozefcipa/novus/internal/nginx"
 	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/proxy_manager"
+	"github.com/jozefcipa/novus/internal/settings"
 	"github.com/jozefcipa/novus/internal/tui"
 	"github.com/spf13/cobra"
 )
@@ -12,27 +15,35 @@ import (
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show status of services and registered routes",
-	Long: `Show whether Nginx and DNSMasq services are running,
+	Long: `Show whether the proxy and DNS services are running,
 and print a list of all URLs that are registered by Novus.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		nginxLoader := logger.Loadingf("Checking Nginx status")
-		isNginxRunning := nginx.IsRunning()
-		if isNginxRunning {
-			nginxLoader.Checkf("Nginx running")
-			logger.Debugf("Nginx configuration loaded from %s", nginx.NginxServersDir)
+		proxyLoader := logger.Loadingf("Checking %s status", proxy_manager.ServerName())
+		isProxyRunning := proxy_manager.IsRunning()
+		if isProxyRunning {
+			proxyLoader.Checkf("%s running", proxy_manager.ServerName())
+			if !settings.UseBuiltinProxy() {
+				logger.Debugf("Nginx configuration loaded from %s", paths.NginxServersDir)
+			}
 		} else {
-			nginxLoader.Errorf("Nginx not running")
+			proxyLoader.Errorf("%s not running", proxy_manager.ServerName())
 		}
 
-		dnsmasqLoader := logger.Loadingf("Checking DNSMasq status")
-		isDNSMasqRunning := dnsmasq.IsRunning()
-		if isDNSMasqRunning {
-			dnsmasqLoader.Checkf("DNSMasq running")
+		dnsLoader := logger.Loadingf("Checking %s status", dns_manager.ServerName())
+		isDNSRunning := dns_manager.IsRunning()
+		if isDNSRunning {
+			dnsLoader.Checkf("%s running", dns_manager.ServerName())
 		} else {
-			dnsmasqLoader.Errorf("DNSMasq not running")
+			dnsLoader.Errorf("%s not running", dns_manager.ServerName())
 		}
 
-		if !isNginxRunning || !isDNSMasqRunning {
+		if agent.IsRunning() {
+			logger.Checkf("Novus agent running")
+		} else {
+			logger.Infof("Novus agent not running (control API is unavailable)")
+		}
+
+		if !isProxyRunning || !isDNSRunning {
 			logger.Hintf("Run \"novus start\" to start routing.")
 		} else {
 			// All good, show the routing info
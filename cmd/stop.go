@@ -1,23 +1,30 @@
 package cmd
 
 import (
+	"github.com/jozefcipa/novus/internal/agent"
 	"github.com/jozefcipa/novus/internal/config"
-	"github.com/jozefcipa/novus/internal/dnsmasq"
-	"github.com/jozefcipa/novus/internal/nginx"
+	"github.com/jozefcipa/novus/internal/dns_manager"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/proxy_manager"
 
 	"github.com/spf13/cobra"
 )
 
 var stopCmd = &cobra.Command{
 	Use:   "stop",
-	Short: "Stop Nginx and DNSMasq services",
+	Short: "Stop proxy and DNS services",
 	Long: `Running this command will stop the HTTP and DNS servers,
 so Novus will no longer serve application requests to the URLs
 defined in the ` + config.ConfigFileName + ` configuration file.
 	`,
+	Annotations: lockState,
 	Run: func(cmd *cobra.Command, args []string) {
-		nginx.Stop()
-		dnsmasq.Stop()
+		proxy_manager.Stop()
+		dns_manager.Stop()
+
+		if agent.Stop() {
+			logger.Infof("🚫 Novus agent stopped")
+		}
 	},
 }
 
@@ -4,8 +4,10 @@ import (
 	"os"
 
 	"github.com/jozefcipa/novus/internal/fs"
+	"github.com/jozefcipa/novus/internal/journal"
 	"github.com/jozefcipa/novus/internal/logger"
 	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/process"
 	"github.com/jozefcipa/novus/internal/sudo"
 	"github.com/jozefcipa/novus/internal/tui"
 	"github.com/spf13/cobra"
@@ -14,9 +16,10 @@ import (
 var revokeTrustFlag bool
 
 var trustCmd = &cobra.Command{
-	Use:   "trust",
-	Short: "Enable passwordless use of Novus",
-	Long:  "Create a sudoers file so Novus can be run without prompting for `sudo` password",
+	Use:         "trust",
+	Short:       "Enable passwordless use of Novus",
+	Long:        "Create a sudoers file so Novus can be run without prompting for `sudo` password",
+	Annotations: lockState,
 	Run: func(cmd *cobra.Command, args []string) {
 		sudoersExists := fs.FileExists(paths.SudoersFilePath)
 
@@ -30,10 +33,11 @@ var trustCmd = &cobra.Command{
 				err := sudo.DeleteFile(paths.SudoersFilePath)
 				if err != nil {
 					logger.Errorf(err.Error())
-					os.Exit(1)
+					process.Exit(1)
 				}
 
 				logger.Infof("🚫 Novus trust revoked")
+				journal.Record(journal.Entry{Command: "trust", Args: []string{"--revoke"}})
 			} else {
 				logger.Hintf("Novus trust is already revoked.")
 			}
@@ -51,6 +55,7 @@ var trustCmd = &cobra.Command{
 		logger.Infof("Creating sudoers file...")
 		sudo.RegisterSudoersFile()
 		logger.Checkf("Novus is now trusted and can be used without sudo password.")
+		journal.Record(journal.Entry{Command: "trust"})
 	},
 }
 
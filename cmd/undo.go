@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/jozefcipa/novus/internal/config"
+	"github.com/jozefcipa/novus/internal/diff_manager"
+	"github.com/jozefcipa/novus/internal/dns_manager"
+	"github.com/jozefcipa/novus/internal/domain_cleanup_manager"
+	"github.com/jozefcipa/novus/internal/dry_run"
+	"github.com/jozefcipa/novus/internal/fs"
+	"github.com/jozefcipa/novus/internal/journal"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/proxy_manager"
+	"github.com/jozefcipa/novus/internal/sudo"
+	"github.com/jozefcipa/novus/internal/transaction"
+	"github.com/jozefcipa/novus/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var undoCmd = &cobra.Command{
+	Use:         "undo",
+	Short:       "Revert the last change",
+	Long:        "Revert the last command that changed the routing by re-applying the previous state of the app. Run `novus history` to see what will be reverted.",
+	Annotations: lockState,
+	Run: func(cmd *cobra.Command, args []string) {
+		entry, exists := journal.LastUndoable()
+		if !exists {
+			logger.Infof("There is nothing to undo.")
+			return
+		}
+
+		command := strings.TrimSpace(entry.Command + " " + strings.Join(entry.Args, " "))
+		if !dry_run.Enabled && !skipConfirmationFlag && !tui.Confirm(fmt.Sprintf("Do you want to revert \"novus %s\" (#%d)?", command, entry.ID)) {
+			os.Exit(0)
+		}
+
+		if entry.Command == "trust" {
+			undoTrust(entry)
+		} else {
+			undoAppChange(entry)
+		}
+
+		if dry_run.Enabled {
+			dry_run.PrintPlan()
+			return
+		}
+
+		journal.MarkUndone(entry.ID)
+		logger.Checkf("\"novus %s\" has been reverted", command)
+	},
+}
+
+func undoTrust(entry journal.Entry) {
+	revoked := slices.Contains(entry.Args, "--revoke")
+
+	if revoked {
+		if dry_run.Enabled {
+			logger.Infof("Dry run: sudoers file %s would be created", paths.SudoersFilePath)
+			return
+		}
+		if !fs.FileExists(paths.SudoersFilePath) {
+			sudo.RegisterSudoersFile()
+		}
+	} else if fs.FileExists(paths.SudoersFilePath) {
+		if err := sudo.DeleteFile(paths.SudoersFilePath); err != nil {
+			logger.Errorf(err.Error())
+			process.Exit(1)
+		}
+	}
+
+	journal.Record(journal.Entry{Command: "trust", UndoOf: entry.ID})
+}
+
+func undoAppChange(entry journal.Entry) {
+	before := journal.SnapshotApp(entry.App)
+
+	// Track all files that will be modified, so they can be restored if anything fails
+	transaction.Begin()
+
+	novusState := novus.GetState()
+
+	// Stop routing the current version of the app first,
+	// domains that are not part of the previous state are cleaned up the same way as by `novus remove`
+	if appState, exists := novus.GetAppState(entry.App); exists && appState.Status == novus.APP_ACTIVE {
+		removedRoutes := appState.Routes
+		if entry.Before != nil && entry.Before.Status == novus.APP_ACTIVE {
+			// Routes that remain active keep their certificates and DNS records
+			_, removedRoutes, _ = diff_manager.DetectConfigDiff(config.NovusConfig{AppName: entry.App, Routes: entry.Before.Routes}, *appState)
+		}
+
+		if len(removedRoutes) > 0 {
+			domain_cleanup_manager.RemoveDomains(removedRoutes, entry.App, novusState)
+		}
+		proxy_manager.RemoveConfiguration(entry.App)
+		appState.Status = novus.APP_PAUSED
+	}
+
+	if entry.Before == nil {
+		// The app didn't exist before the command
+		novus.RemoveAppState(entry.App)
+	} else {
+		// Restore the previous routes, the app stays paused until it's resumed below
+		appState := novus.InitializeAppState(entry.App, entry.Before.Directory)
+		appState.Directory = entry.Before.Directory
+		appState.Routes = slices.Clone(entry.Before.Routes)
+		appState.Status = novus.APP_PAUSED
+
+		if entry.Before.Status == novus.APP_ACTIVE {
+			resumeApp(entry.App, appState)
+		}
+	}
+
+	// Restart services
+	proxy_manager.Reload()
+	dns_manager.Restart()
+
+	if dry_run.Enabled {
+		return
+	}
+
+	tui.PrintRoutingTable(*novus.GetState())
+
+	// Save state to file
+	novus.SaveState()
+
+	// All changes have been applied successfully
+	transaction.Commit()
+
+	journal.Record(journal.Entry{
+		Command: "undo",
+		App:     entry.App,
+		Before:  before,
+		After:   journal.SnapshotApp(entry.App),
+		UndoOf:  entry.ID,
+	})
+}
+
+func init() {
+	undoCmd.Flags().BoolVar(&dry_run.Enabled, "dry-run", false, "show what would be changed without applying anything")
+	undoCmd.Flags().BoolVarP(&skipConfirmationFlag, "yes", "y", false, "revert without asking for confirmation")
+	rootCmd.AddCommand(undoCmd)
+}
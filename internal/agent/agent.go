@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/daemon"
+	"github.com/jozefcipa/novus/internal/dry_run"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/proxy_manager"
+)
+
+// The agent is a small background process that serves the Novus control API
+const daemonName = "agent"
+
+const logRotationInterval = time.Hour
+
+func EnsureRunning() {
+	if dry_run.Enabled {
+		return
+	}
+
+	if _, err := EnsureToken(); err != nil {
+		logger.Warnf("Novus agent could not be started: %v", err)
+		return
+	}
+
+	if err := daemon.Start(daemonName, "agent", "run"); err != nil {
+		// The control API is optional, so don't fail the whole command
+		logger.Warnf("Novus agent could not be started: %v", err)
+		logger.Hintf("Run \"novus agent start\" to try again.")
+	}
+}
+
+func Start() error {
+	if _, err := EnsureToken(); err != nil {
+		return err
+	}
+
+	return daemon.Start(daemonName, "agent", "run")
+}
+
+func Stop() bool {
+	return daemon.Stop(daemonName)
+}
+
+func IsRunning() bool {
+	_, running := daemon.IsRunning(daemonName)
+	return running
+}
+
+func LogFilePath() string {
+	return daemon.LogFilePath(daemonName)
+}
+
+// Serve runs the API server in the foreground until the process is terminated
+func Serve() error {
+	apiMux := http.NewServeMux()
+	registerHandlers(apiMux)
+
+	// Metrics reveal app names, domains and upstream errors, so they require the API token as well
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", withAuth(newMetricsHandler()))
+	mux.Handle("/", withAuth(apiMux))
+
+	server := &http.Server{
+		Addr:              novus.NovusAgentAddress,
+		Handler:           withCORS(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Shut down gracefully on termination
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	go rotateLogsPeriodically()
+
+	logger.Infof("Novus agent listening on %s", novus.NovusAgentAddress)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// Request logs are checked regularly so they don't grow forever
+func rotateLogsPeriodically() {
+	for {
+		proxy_manager.RotateLogs()
+		time.Sleep(logRotationInterval)
+	}
+}
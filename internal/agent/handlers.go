@@ -0,0 +1,268 @@
+package agent
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jozefcipa/novus/internal/config"
+	"github.com/jozefcipa/novus/internal/fs"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/maputils"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/sharedtypes"
+	"github.com/jozefcipa/novus/internal/subcommand"
+	"github.com/jozefcipa/novus/internal/validation"
+)
+
+type appResponse struct {
+	Name      string              `json:"name"`
+	Status    novus.AppStatus     `json:"status"`
+	Directory string              `json:"directory"`
+	Global    bool                `json:"global"`
+	Routes    []sharedtypes.Route `json:"routes"`
+	// Used to display certificates expiration
+	Certificates sharedtypes.DomainCertificates `json:"certificates"`
+}
+
+type routeResponse struct {
+	App    string          `json:"app"`
+	Status novus.AppStatus `json:"status"`
+	sharedtypes.Route
+}
+
+type addRouteRequest struct {
+	Domain   string `json:"domain"`
+	Upstream string `json:"upstream"`
+}
+
+func registerHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/apps", listApps)
+	mux.HandleFunc("GET /api/apps/{app}", getApp)
+	mux.HandleFunc("POST /api/apps/{app}/pause", pauseApp)
+	mux.HandleFunc("POST /api/apps/{app}/resume", resumeApp)
+	mux.HandleFunc("DELETE /api/apps/{app}", removeApp)
+	mux.HandleFunc("GET /api/routes", listRoutes)
+	mux.HandleFunc("POST /api/routes", addGlobalRoute)
+	mux.HandleFunc("DELETE /api/routes/{domain}", removeGlobalRoute)
+	mux.HandleFunc("POST /api/apply", applyAll)
+	mux.HandleFunc("GET /api/health", checkHealth)
+}
+
+// Handlers run concurrently, but the state is stored in a global variable
+var stateMutex sync.Mutex
+
+// Returns the current state from the state file, or writes an error response if it can't be loaded
+// (the agent runs in the background, so it must not prompt for a backup restore or exit).
+// Every reload allocates new maps, so the returned copy is not modified by subsequent reloads
+func readState(w http.ResponseWriter) (novus.NovusState, bool) {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+
+	novusState, err := novus.TryReloadState()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to load Novus state: "+err.Error())
+		return novus.NovusState{}, false
+	}
+
+	return *novusState, true
+}
+
+func toAppResponse(appName string, appState *novus.AppState) appResponse {
+	return appResponse{
+		Name:      appName,
+		Status:    appState.Status,
+		Directory: appState.Directory,
+		Global:    appName == novus.GlobalAppName,
+		Routes:    appState.Routes,
+
+		Certificates: appState.SSLCertificates,
+	}
+}
+
+// Returns all user apps sorted by name (internal Novus app is excluded)
+func sortedAppNames(novusState novus.NovusState) []string {
+	appNames := slices.DeleteFunc(maputils.MapKeys(novusState.Apps), func(appName string) bool {
+		return appName == novus.NovusInternalAppName
+	})
+	slices.SortFunc(appNames, func(a, b string) int { return cmp.Compare(a, b) })
+
+	return appNames
+}
+
+func listApps(w http.ResponseWriter, r *http.Request) {
+	novusState, ok := readState(w)
+	if !ok {
+		return
+	}
+
+	apps := []appResponse{}
+	for _, appName := range sortedAppNames(novusState) {
+		apps = append(apps, toAppResponse(appName, novusState.Apps[appName]))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"apps": apps})
+}
+
+func getApp(w http.ResponseWriter, r *http.Request) {
+	appName := r.PathValue("app")
+	novusState, ok := readState(w)
+	if !ok {
+		return
+	}
+
+	appState, exists := novusState.Apps[appName]
+	if !exists || appName == novus.NovusInternalAppName {
+		writeError(w, http.StatusNotFound, "App \""+appName+"\" does not exist")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toAppResponse(appName, appState))
+}
+
+func listRoutes(w http.ResponseWriter, r *http.Request) {
+	novusState, ok := readState(w)
+	if !ok {
+		return
+	}
+
+	routes := []routeResponse{}
+	for _, appName := range sortedAppNames(novusState) {
+		appState := novusState.Apps[appName]
+		for _, route := range appState.Routes {
+			routes = append(routes, routeResponse{App: appName, Status: appState.Status, Route: route})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"routes": routes})
+}
+
+func pauseApp(w http.ResponseWriter, r *http.Request) {
+	runAppCommand(w, r.PathValue("app"), "pause")
+}
+
+func resumeApp(w http.ResponseWriter, r *http.Request) {
+	runAppCommand(w, r.PathValue("app"), "resume")
+}
+
+func removeApp(w http.ResponseWriter, r *http.Request) {
+	runAppCommand(w, r.PathValue("app"), "remove", "--yes")
+}
+
+func runAppCommand(w http.ResponseWriter, appName string, args ...string) {
+	novusState, ok := readState(w)
+	if !ok {
+		return
+	}
+
+	if _, exists := novusState.Apps[appName]; !exists || appName == novus.NovusInternalAppName || appName == novus.GlobalAppName {
+		writeError(w, http.StatusNotFound, "App \""+appName+"\" does not exist")
+		return
+	}
+
+	// "--" makes sure the app name is never parsed as a flag
+	output, err := subcommand.Run("", append(args, "--", appName)...)
+	writeCommandResult(w, output, err)
+}
+
+func addGlobalRoute(w http.ResponseWriter, r *http.Request) {
+	var body addRouteRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Domain == "" || body.Upstream == "" {
+		writeError(w, http.StatusBadRequest, "Request body must contain \"domain\" and \"upstream\" fields")
+		return
+	}
+
+	route := sharedtypes.Route{Domain: body.Domain, Upstream: body.Upstream}
+	if err := newValidator().Struct(route); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid route: "+err.Error())
+		return
+	}
+
+	// "--" makes sure the values are never parsed as flags (e.g. "--dry-run")
+	output, err := subcommand.Run("", "serve", "--", route.Domain, route.Upstream)
+	writeCommandResult(w, output, err)
+}
+
+func removeGlobalRoute(w http.ResponseWriter, r *http.Request) {
+	domain := r.PathValue("domain")
+	if err := newValidator().Var(domain, "required,fqdn"); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid domain \""+domain+"\"")
+		return
+	}
+
+	novusState, ok := readState(w)
+	if !ok {
+		return
+	}
+
+	globalApp, exists := novusState.Apps[novus.GlobalAppName]
+	if !exists || !slices.ContainsFunc(globalApp.Routes, func(route sharedtypes.Route) bool { return route.Domain == domain }) {
+		writeError(w, http.StatusNotFound, "Domain \""+domain+"\" is not defined in the global scope")
+		return
+	}
+
+	output, err := subcommand.Run("", "remove", "--yes", "--", domain)
+	writeCommandResult(w, output, err)
+}
+
+// Re-applies configuration of all active apps by running `novus serve` in their directories
+func applyAll(w http.ResponseWriter, r *http.Request) {
+	novusState, ok := readState(w)
+	if !ok {
+		return
+	}
+
+	outputs := map[string]string{}
+	failed := []string{}
+	for _, appName := range sortedAppNames(novusState) {
+		appState := novusState.Apps[appName]
+		// Apps without a config file (e.g. global routes) are already up to date
+		if appState.Status != novus.APP_ACTIVE || !fs.FileExists(filepath.Join(appState.Directory, config.ConfigFileName)) {
+			continue
+		}
+
+		output, err := subcommand.Run(appState.Directory, "serve")
+		outputs[appName] = output
+		if err != nil {
+			logger.Debugf("Failed to apply app %s: %v", appName, err)
+			failed = append(failed, appName)
+		}
+	}
+
+	status := http.StatusOK
+	if len(failed) > 0 {
+		status = http.StatusInternalServerError
+	}
+	writeJSON(w, status, map[string]any{"apps": outputs, "failed": failed})
+}
+
+// Routes are validated with the same rules as the routes in the config file
+func newValidator() *validator.Validate {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	validation.RegisterNonExistentTLDValidator(validate)
+
+	return validate
+}
+
+func writeCommandResult(w http.ResponseWriter, output string, err error) {
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error(), "output": output})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"output": output})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
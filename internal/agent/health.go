@@ -0,0 +1,17 @@
+package agent
+
+import (
+	"net/http"
+
+	"github.com/jozefcipa/novus/internal/health"
+)
+
+// Checks whether the upstreams of all active routes accept connections
+func checkHealth(w http.ResponseWriter, r *http.Request) {
+	novusState, ok := readState(w)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"routes": health.CheckActiveRoutes(novusState)})
+}
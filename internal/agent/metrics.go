@@ -0,0 +1,20 @@
+package agent
+
+import (
+	"net/http"
+
+	"github.com/jozefcipa/novus/internal/metrics"
+)
+
+// Serves request metrics collected from the access logs in the Prometheus format
+func newMetricsHandler() http.Handler {
+	collector := metrics.NewCollector()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Logs are read on every scrape, so there's no need to watch them in the background
+		collector.Update()
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		metrics.WritePrometheus(w, collector.Snapshot())
+	})
+}
@@ -0,0 +1,53 @@
+package agent
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/jozefcipa/novus/internal/fs"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/paths"
+)
+
+// The dashboard on https://index.novus calls the API from a different origin (https://internal.novus)
+func withCORS(next http.Handler) http.Handler {
+	allowedOrigin := "https://" + novus.NovusIndexDomain
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Origin") == allowedOrigin {
+			w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			w.Header().Set("Vary", "Origin")
+		}
+
+		// Preflight requests don't contain the Authorization header
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Requests must contain the "Authorization: Bearer <token>" header
+func withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The token is read on every request, so it can be rotated without restarting the agent
+		token, err := fs.ReadFile(paths.NovusAPITokenFilePath)
+		if err != nil || strings.TrimSpace(token) == "" {
+			writeError(w, http.StatusInternalServerError, "API token is not configured")
+			return
+		}
+
+		providedToken, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(providedToken), []byte(strings.TrimSpace(token))) != 1 {
+			writeError(w, http.StatusUnauthorized, "Invalid or missing API token")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
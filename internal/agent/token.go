@@ -0,0 +1,41 @@
+package agent
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jozefcipa/novus/internal/fs"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/paths"
+)
+
+// EnsureToken returns the API token, a new one is generated if it doesn't exist yet
+func EnsureToken() (string, error) {
+	if token, err := fs.ReadFile(paths.NovusAPITokenFilePath); err == nil && strings.TrimSpace(token) != "" {
+		return strings.TrimSpace(token), nil
+	}
+
+	return RotateToken()
+}
+
+// RotateToken generates a new API token, which invalidates the previous one
+func RotateToken() (string, error) {
+	logger.Debugf("Generating a new API token [%s]", paths.NovusAPITokenFilePath)
+
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("Failed to generate API token: %v", err)
+	}
+	token := hex.EncodeToString(bytes)
+
+	// Only the current user can read the token
+	fs.MakeDirOrExit(paths.NovusStateDir)
+	if err := os.WriteFile(paths.NovusAPITokenFilePath, []byte(token), 0600); err != nil {
+		return "", fmt.Errorf("Failed to save API token to %s: %v", paths.NovusAPITokenFilePath, err)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,471 @@
+package ca
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/dry_run"
+	"github.com/jozefcipa/novus/internal/fs"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/mkcert"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/settings"
+	"github.com/jozefcipa/novus/internal/sharedtypes"
+)
+
+// Local certificate authority issuing the SSL certificates for Novus domains.
+// The root is stored in ~/.novus/ca and installed into the system trust store once.
+// A custom CA (e.g. a company development CA) can be configured in ~/.novus/settings.yml instead.
+
+type authority struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+	// Intermediate certificates sent along with the leaf certificates, empty if the CA is a root
+	chain [][]byte
+	// Certificate the clients need to trust, the root of the chain if it's included in the CA file
+	anchor *x509.Certificate
+}
+
+// Loaded root CA, see Configure()
+var root *authority
+
+// Configure makes sure the root CA exists and is trusted by the system.
+// If mkcert has been used before, its root is imported, so the existing certificates stay trusted.
+func Configure() {
+	if dry_run.Enabled {
+		logger.Debugf("[dry-run] Skipping certificate authority initialization")
+		// The root is still needed to check the existing certificates
+		Load()
+		return
+	}
+
+	if settings.UseCustomCA() {
+		for _, path := range []string{CertFilePath(), keyFilePath()} {
+			if !fs.FileExists(path) {
+				logger.Errorf("CA file %s configured in %s does not exist", path, paths.NovusSettingsFilePath)
+				process.Exit(1)
+			}
+		}
+	} else if !fs.FileExists(paths.CACertFilePath) {
+		fs.MakeDirOrExit(paths.CADir)
+
+		if certFilePath, keyFilePath, exists := mkcert.RootFiles(); exists {
+			importRoot(certFilePath, keyFilePath)
+		} else {
+			createRoot()
+		}
+	}
+
+	root = loadOrExit()
+
+	if !isTrusted(root.cert) {
+		installRoot()
+	}
+	installNSS()
+
+	// Keep the files on https://index.novus/ca up to date
+	WriteDownloadFiles()
+}
+
+// Load loads the root CA if it exists, without creating or installing it
+func Load() bool {
+	if root == nil && fs.FileExists(CertFilePath()) {
+		root = loadOrExit()
+	}
+	return root != nil
+}
+
+// Root returns the certificate of the CA signing the SSL certificates
+func Root() (*x509.Certificate, bool) {
+	if !Load() {
+		return nil, false
+	}
+	return root.cert, true
+}
+
+// IsTrusted returns true if the system trusts the certificates signed by the CA
+func IsTrusted() bool {
+	return Load() && isTrusted(root.cert)
+}
+
+// CertFilePath returns the certificate of the CA in use, the custom CA from the settings takes precedence
+func CertFilePath() string {
+	if settings.UseCustomCA() {
+		return settings.Get().CA.CertFile
+	}
+	return paths.CACertFilePath
+}
+
+func keyFilePath() string {
+	if settings.UseCustomCA() {
+		return settings.Get().CA.KeyFile
+	}
+	return paths.CAKeyFilePath
+}
+
+func loadOrExit() *authority {
+	certs, err := readCertificates(CertFilePath())
+	if err != nil {
+		logger.Errorf("Failed to load the CA certificate: %v", err)
+		process.Exit(1)
+	}
+	cert := certs[0]
+
+	key, err := readPrivateKey(keyFilePath())
+	if err != nil {
+		logger.Errorf("Failed to load the CA key: %v", err)
+		process.Exit(1)
+	}
+
+	if !cert.IsCA || (cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageCertSign == 0) {
+		logger.Errorf("Certificate %s can't be used to sign other certificates", CertFilePath())
+		process.Exit(1)
+	}
+	if publicKey, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool }); !ok || !publicKey.Equal(cert.PublicKey) {
+		logger.Errorf("Key %s doesn't belong to the CA certificate %s", keyFilePath(), CertFilePath())
+		process.Exit(1)
+	}
+
+	// Roots are already trusted by the clients, only the intermediates need to be sent
+	chain := [][]byte{}
+	anchor := cert
+	for _, chainCert := range certs {
+		if bytes.Equal(chainCert.RawIssuer, chainCert.RawSubject) {
+			anchor = chainCert
+		} else {
+			chain = append(chain, chainCert.Raw)
+		}
+	}
+
+	logger.Debugf("CA loaded [%s]", SubjectName(cert))
+	return &authority{cert: cert, key: key, chain: chain, anchor: anchor}
+}
+
+// Copies the mkcert root CA files to ~/.novus/ca
+func importRoot(certFilePath string, keyFilePath string) {
+	logger.Debugf("Importing mkcert root CA [%s]", filepath.Dir(certFilePath))
+
+	for src, dst := range map[string]string{certFilePath: paths.CACertFilePath, keyFilePath: paths.CAKeyFilePath} {
+		content, err := os.ReadFile(src)
+		if err != nil {
+			logger.Errorf("Failed to read %s: %v", src, err)
+			process.Exit(1)
+		}
+		if err := os.WriteFile(dst, content, 0600); err != nil {
+			logger.Errorf("Failed to write %s: %v", dst, err)
+			process.Exit(1)
+		}
+	}
+
+	logger.Checkf("mkcert root CA imported from %s", filepath.Dir(certFilePath))
+}
+
+func createRoot() {
+	logger.Debugf("Creating root CA [%s]", paths.CADir)
+
+	key, err := rsa.GenerateKey(rand.Reader, 3072)
+	if err != nil {
+		logger.Errorf("Failed to generate the root CA key: %v", err)
+		process.Exit(1)
+	}
+
+	// Subject key identifier, as defined in RFC 5280
+	publicKey, _ := x509.MarshalPKIXPublicKey(key.Public())
+	skid := sha1.Sum(publicKey)
+
+	template := &x509.Certificate{
+		SerialNumber: randomSerialNumber(),
+		Subject: pkix.Name{
+			Organization:       []string{"Novus development CA"},
+			OrganizationalUnit: []string{userAndHostname()},
+			CommonName:         "Novus " + userAndHostname(),
+		},
+		SubjectKeyId: skid[:],
+
+		NotBefore: time.Now(),
+		NotAfter:  time.Now().AddDate(10, 0, 0),
+
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		logger.Errorf("Failed to create the root certificate: %v", err)
+		process.Exit(1)
+	}
+
+	writePEMOrExit(paths.CAKeyFilePath, "PRIVATE KEY", 0400, marshalPrivateKey(key))
+	writePEMOrExit(paths.CACertFilePath, "CERTIFICATE", 0644, certDER)
+
+	logger.Checkf("Novus certificate authority created [%s]", paths.CADir)
+}
+
+// IssueCertificate creates a certificate for the given domains (wildcards and IP addresses are supported)
+// signed by the root CA and stores it in the given directory
+func IssueCertificate(domains []string, dirPath string) sharedtypes.Certificate {
+	certFilePath := filepath.Join(dirPath, "cert.pem")
+	keyFilePath := filepath.Join(dirPath, "key.pem")
+
+	if dry_run.Enabled {
+		dry_run.Record(dry_run.Action{Type: dry_run.IssueCertificate, Target: strings.Join(domains, ", ")})
+
+		return sharedtypes.Certificate{
+			CertFilePath: certFilePath,
+			KeyFilePath:  keyFilePath,
+			ExpiresAt:    time.Now().AddDate(2, 3, 0),
+		}
+	}
+
+	if root == nil {
+		root = loadOrExit()
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		logger.Errorf("Failed to generate a certificate key: %v", err)
+		process.Exit(1)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: randomSerialNumber(),
+		Subject: pkix.Name{
+			Organization:       []string{"Novus development certificate"},
+			OrganizationalUnit: []string{userAndHostname()},
+		},
+
+		NotBefore: time.Now(),
+		// Certificates last for 2 years and 3 months, which is always less than
+		// 825 days, the limit that macOS/iOS apply to all certificates,
+		// including custom roots. See https://support.apple.com/en-us/HT210176.
+		NotAfter: time.Now().AddDate(2, 3, 0),
+
+		KeyUsage:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	// Custom CAs might expire sooner, certificates can't outlive them
+	if template.NotAfter.After(root.cert.NotAfter) {
+		template.NotAfter = root.cert.NotAfter
+	}
+	for _, domain := range domains {
+		if ip := net.ParseIP(domain); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, domain)
+		}
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, root.cert, key.Public(), root.key)
+	if err != nil {
+		logger.Errorf("Failed to create a certificate for %s: %v", strings.Join(domains, ", "), err)
+		process.Exit(1)
+	}
+
+	writePEMOrExit(keyFilePath, "PRIVATE KEY", 0600, marshalPrivateKey(key))
+	writePEMOrExit(certFilePath, "CERTIFICATE", 0644, slices.Concat([][]byte{certDER}, root.chain)...)
+
+	// Read the metadata back from the file, so the state always matches the actual certificate
+	cert, err := ReadCertificate(certFilePath)
+	if err != nil {
+		logger.Errorf("Failed to read the certificate: %v", err)
+		process.Exit(1)
+	}
+
+	return sharedtypes.Certificate{
+		CertFilePath: certFilePath,
+		KeyFilePath:  keyFilePath,
+		ExpiresAt:    cert.NotAfter,
+		SerialNumber: FormatSerialNumber(cert.SerialNumber),
+	}
+}
+
+// ReadCertificate parses the first certificate in the PEM file
+func ReadCertificate(path string) (*x509.Certificate, error) {
+	certs, err := readCertificates(path)
+	if err != nil {
+		return nil, err
+	}
+	return certs[0], nil
+}
+
+func readCertificates(path string) ([]*x509.Certificate, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	certs := []*x509.Certificate{}
+	for {
+		var block *pem.Block
+		block, content = pem.Decode(content)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate found in %s", path)
+	}
+	return certs, nil
+}
+
+// IsIssuedByRoot returns true if the certificate has been signed by the current root CA
+func IsIssuedByRoot(cert *x509.Certificate) bool {
+	if root == nil {
+		return false
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots: roots,
+		// Expiration is checked separately, so the certificate can be renewed in time
+		CurrentTime: cert.NotBefore,
+	})
+
+	return err == nil
+}
+
+func FormatSerialNumber(serialNumber *big.Int) string {
+	return strings.ToUpper(serialNumber.Text(16))
+}
+
+func readPrivateKey(path string) (crypto.Signer, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("no private key found in %s", path)
+	}
+
+	// mkcert stores the keys as PKCS #8, other tools often use PKCS #1 or SEC 1
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, errors.New("unsupported private key type")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("failed to parse the private key in %s", path)
+}
+
+func marshalPrivateKey(key crypto.Signer) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		logger.Errorf("Failed to encode the private key: %v", err)
+		process.Exit(1)
+	}
+	return der
+}
+
+func writePEMOrExit(path string, blockType string, perm os.FileMode, blocks ...[]byte) {
+	content := []byte{}
+	for _, der := range blocks {
+		content = append(content, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})...)
+	}
+
+	// The root key is read-only, so it has to be removed first
+	os.Remove(path)
+	if err := os.WriteFile(path, content, perm); err != nil {
+		logger.Errorf("Failed to write %s: %v", path, err)
+		process.Exit(1)
+	}
+}
+
+func randomSerialNumber() *big.Int {
+	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
+	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	if err != nil {
+		logger.Errorf("Failed to generate a serial number: %v", err)
+		process.Exit(1)
+	}
+	return serialNumber
+}
+
+func userAndHostname() string {
+	var result string
+	if u, err := user.Current(); err == nil {
+		result = u.Username + "@"
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		result += hostname
+	}
+	return result
+}
+
+// Fingerprint returns the SHA-256 fingerprint of the certificate in the usual colon-separated format
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// IssuerName returns a human-readable name of the certificate issuer
+func IssuerName(cert *x509.Certificate) string {
+	return displayName(cert.Issuer)
+}
+
+// SubjectName returns a human-readable name of the certificate owner
+func SubjectName(cert *x509.Certificate) string {
+	return displayName(cert.Subject)
+}
+
+func displayName(name pkix.Name) string {
+	if name.CommonName != "" {
+		return name.CommonName
+	}
+	if len(name.Organization) > 0 {
+		return name.Organization[0]
+	}
+	return name.String()
+}
+
+// SANs returns all domains and IP addresses the certificate is valid for
+func SANs(cert *x509.Certificate) []string {
+	sans := slices.Clone(cert.DNSNames)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	return sans
+}
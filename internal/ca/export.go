@@ -0,0 +1,139 @@
+package ca
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/jozefcipa/novus/internal/fs"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/paths"
+)
+
+type ExportFormat string
+
+const (
+	EXPORT_PEM          ExportFormat = "pem"
+	EXPORT_DER          ExportFormat = "der"
+	EXPORT_MOBILECONFIG ExportFormat = "mobileconfig"
+)
+
+var ExportFormats = []ExportFormat{EXPORT_PEM, EXPORT_DER, EXPORT_MOBILECONFIG}
+
+// Files served on https://index.novus/ca (and http://<LAN IP>/ca), DER uses .crt as Android only accepts that extension
+var downloadFileNames = map[ExportFormat]string{
+	EXPORT_PEM:          "novus-rootCA.pem",
+	EXPORT_DER:          "novus-rootCA.crt",
+	EXPORT_MOBILECONFIG: "novus-rootCA.mobileconfig",
+}
+
+// DownloadFileName returns the name of the exported CA file for the format
+func DownloadFileName(format ExportFormat) string {
+	return downloadFileNames[format]
+}
+
+// Export returns the CA certificate other devices should trust in the given format
+func Export(format ExportFormat) ([]byte, error) {
+	if !Load() {
+		return nil, fmt.Errorf("certificate authority has not been created yet, run \"novus serve\" first")
+	}
+
+	switch format {
+	case EXPORT_PEM:
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.anchor.Raw}), nil
+	case EXPORT_DER:
+		return root.anchor.Raw, nil
+	case EXPORT_MOBILECONFIG:
+		return mobileConfig(), nil
+	default:
+		return nil, fmt.Errorf("unsupported format \"%s\", use one of %v", format, ExportFormats)
+	}
+}
+
+// WriteDownloadFiles exports the CA in all formats to ~/.novus/ca/download, so the proxy can serve them
+func WriteDownloadFiles() {
+	fs.MakeDirOrExit(paths.CADownloadDir)
+
+	for _, format := range ExportFormats {
+		content, err := Export(format)
+		if err != nil {
+			logger.Debugf("Failed to export CA as %s: %v", format, err)
+			return
+		}
+
+		filePath := filepath.Join(paths.CADownloadDir, DownloadFileName(format))
+		if current, err := os.ReadFile(filePath); err == nil && bytes.Equal(current, content) {
+			continue
+		}
+
+		logger.Debugf("Writing CA download file [%s]", filePath)
+		if err := os.WriteFile(filePath, content, 0644); err != nil {
+			logger.Warnf("Failed to write %s: %v", filePath, err)
+		}
+	}
+}
+
+// Apple configuration profile installing the CA as a trusted root
+// https://developer.apple.com/documentation/devicemanagement/certificateroot
+func mobileConfig() []byte {
+	name := escapeXML(SubjectName(root.anchor))
+
+	return []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>PayloadContent</key>
+	<array>
+		<dict>
+			<key>PayloadCertificateFileName</key>
+			<string>` + downloadFileNames[EXPORT_DER] + `</string>
+			<key>PayloadContent</key>
+			<data>` + base64.StdEncoding.EncodeToString(root.anchor.Raw) + `</data>
+			<key>PayloadDescription</key>
+			<string>Adds the Novus certificate authority</string>
+			<key>PayloadDisplayName</key>
+			<string>` + name + `</string>
+			<key>PayloadIdentifier</key>
+			<string>com.apple.security.root.` + profileUUID("certificate") + `</string>
+			<key>PayloadType</key>
+			<string>com.apple.security.root</string>
+			<key>PayloadUUID</key>
+			<string>` + profileUUID("certificate") + `</string>
+			<key>PayloadVersion</key>
+			<integer>1</integer>
+		</dict>
+	</array>
+	<key>PayloadDisplayName</key>
+	<string>Novus CA (` + name + `)</string>
+	<key>PayloadIdentifier</key>
+	<string>novus.ca.` + profileUUID("profile") + `</string>
+	<key>PayloadRemovalDisallowed</key>
+	<false/>
+	<key>PayloadType</key>
+	<string>Configuration</string>
+	<key>PayloadUUID</key>
+	<string>` + profileUUID("profile") + `</string>
+	<key>PayloadVersion</key>
+	<integer>1</integer>
+</dict>
+</plist>
+`)
+}
+
+// UUIDs are derived from the certificate, so the profile only changes with the CA
+func profileUUID(payload string) string {
+	sum := sha256.Sum256(slices.Concat([]byte(payload), root.anchor.Raw))
+	return fmt.Sprintf("%X-%X-%X-%X-%X", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+func escapeXML(value string) string {
+	var escaped bytes.Buffer
+	xml.EscapeText(&escaped, []byte(value))
+	return escaped.String()
+}
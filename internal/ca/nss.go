@@ -0,0 +1,143 @@
+package ca
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/jozefcipa/novus/internal/fs"
+	"github.com/jozefcipa/novus/internal/homebrew"
+	"github.com/jozefcipa/novus/internal/logger"
+)
+
+// Firefox (on all platforms) and Chrome (on Linux) don't use the system trust store, but their own NSS databases.
+// The root is installed there with `certutil` if it's available
+// (same as https://github.com/FiloSottile/mkcert/blob/2a46726cebac0ff4e1f133d90b4e4c42f1edf44a/truststore_nss.go)
+
+type NSSStatus int
+
+const (
+	// No Firefox or Chrome profile has been found
+	NSS_NOT_USED NSSStatus = iota
+	NSS_TRUSTED
+	NSS_NOT_TRUSTED
+	// NSS databases exist, but `certutil` is not installed, so the trust can't be checked nor installed
+	NSS_CERTUTIL_MISSING
+)
+
+// NSSTrustStatus checks whether all Firefox and Chrome NSS databases trust the CA
+func NSSTrustStatus() NSSStatus {
+	databases := nssDatabases()
+	if len(databases) == 0 || !Load() {
+		return NSS_NOT_USED
+	}
+
+	certutil, found := certutilPath()
+	if !found {
+		return NSS_CERTUTIL_MISSING
+	}
+
+	for _, database := range databases {
+		if !isTrustedByNSS(certutil, database) {
+			return NSS_NOT_TRUSTED
+		}
+	}
+
+	return NSS_TRUSTED
+}
+
+// CertutilInstallHint returns the command that installs `certutil` on the current platform
+func CertutilInstallHint() string {
+	if runtime.GOOS == "darwin" {
+		return "brew install nss"
+	}
+	return "sudo apt install libnss3-tools (Debian, Ubuntu), sudo dnf install nss-tools (Fedora) or sudo pacman -S nss (Arch)"
+}
+
+// Installs the root into the NSS databases that don't trust it yet, browsers are optional, so failures are only reported
+func installNSS() {
+	databases := nssDatabases()
+	if len(databases) == 0 {
+		return
+	}
+
+	certutil, found := certutilPath()
+	if !found {
+		logger.Debugf("Skipping NSS trust store, certutil is not installed [databases=%v]", databases)
+		return
+	}
+
+	for _, database := range databases {
+		if isTrustedByNSS(certutil, database) {
+			continue
+		}
+
+		logger.Infof("🔒 Installing the CA certificate into the Firefox/Chrome trust store [%s]", strings.SplitN(database, ":", 2)[1])
+		command := []string{certutil, "-A", "-d", database, "-t", "C,,", "-n", nssNickname(), "-i", CertFilePath()}
+		logger.Debugf("Running \"%s\"", strings.Join(command, " "))
+		if out, err := exec.Command(command[0], command[1:]...).CombinedOutput(); err != nil {
+			logger.Warnf("Failed to install the CA certificate into %s: %v\n%s", database, err, out)
+			continue
+		}
+		logger.Checkf("CA certificate installed into the Firefox/Chrome trust store")
+	}
+}
+
+func isTrustedByNSS(certutil string, database string) bool {
+	err := exec.Command(certutil, "-V", "-d", database, "-u", "L", "-n", nssNickname()).Run()
+	logger.Debugf("Checking NSS trust store [%s, trusted=%t]", database, err == nil)
+	return err == nil
+}
+
+// Each CA is stored under a unique name, so a previous Novus CA is not mistaken for the current one
+func nssNickname() string {
+	return "Novus CA " + FormatSerialNumber(root.cert.SerialNumber)
+}
+
+func certutilPath() (string, bool) {
+	if path, err := exec.LookPath("certutil"); err == nil {
+		return path, true
+	}
+
+	// NSS from Homebrew is keg-only
+	if runtime.GOOS == "darwin" && homebrew.IsInstalled() {
+		path := filepath.Join(homebrew.Prefix(), "opt/nss/bin/certutil")
+		return path, fs.FileExists(path)
+	}
+
+	return "", false
+}
+
+// Returns the NSS databases of Firefox profiles and Chrome/Chromium (Linux) in the certutil format (e.g. "sql:/path")
+func nssDatabases() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return []string{}
+	}
+
+	profileDirs := []string{
+		filepath.Join(home, ".pki/nssdb"),
+		filepath.Join(home, "snap/chromium/current/.pki/nssdb"),
+	}
+	for _, pattern := range []string{
+		filepath.Join(home, ".mozilla/firefox/*"),
+		filepath.Join(home, "snap/firefox/common/.mozilla/firefox/*"),
+		filepath.Join(home, "Library/Application Support/Firefox/Profiles/*"),
+	} {
+		matches, _ := filepath.Glob(pattern)
+		profileDirs = append(profileDirs, matches...)
+	}
+
+	databases := []string{}
+	for _, dir := range profileDirs {
+		if fs.FileExists(filepath.Join(dir, "cert9.db")) {
+			databases = append(databases, "sql:"+dir)
+		} else if fs.FileExists(filepath.Join(dir, "cert8.db")) {
+			databases = append(databases, "dbm:"+dir)
+		}
+	}
+
+	return databases
+}
@@ -0,0 +1,84 @@
+package ca
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/jozefcipa/novus/internal/fs"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/process"
+)
+
+// Name of the root certificate in the system trust store
+const trustedRootFileName = "novus-rootCA.crt"
+
+// Trust store locations of the common Linux distributions and the commands that rebuild the CA bundle
+// (same as https://github.com/FiloSottile/mkcert/blob/2a46726cebac0ff4e1f133d90b4e4c42f1edf44a/truststore_linux.go)
+var linuxTrustStores = []struct {
+	dir           string
+	updateCommand []string
+}{
+	{dir: "/etc/pki/ca-trust/source/anchors", updateCommand: []string{"update-ca-trust", "extract"}},
+	{dir: "/usr/local/share/ca-certificates", updateCommand: []string{"update-ca-certificates"}},
+	{dir: "/etc/ca-certificates/trust-source/anchors", updateCommand: []string{"trust", "extract-compat"}},
+	{dir: "/usr/share/pki/trust/anchors", updateCommand: []string{"update-ca-certificates"}},
+}
+
+// Checks whether the system verifies the root certificate (macOS keychain or the Linux CA bundle)
+func isTrusted(cert *x509.Certificate) bool {
+	_, err := cert.Verify(x509.VerifyOptions{})
+	return err == nil
+}
+
+func installRoot() {
+	commands, err := trustStoreCommands()
+	if err != nil {
+		logger.Warnf("CA certificate could not be installed: %v", err)
+		logger.Hintf("Add %s to the trusted certificates manually.", CertFilePath())
+		return
+	}
+
+	logger.Infof("🔒 Installing the CA certificate %s into the system trust store (sudo password might be required)", SubjectName(root.cert))
+	for _, command := range commands {
+		commandString := strings.Join(command, " ")
+		logger.Infof("   $ %s", commandString)
+
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			logger.Errorf("Failed to run \"%s\": %v", commandString, err)
+			logger.Hintf("Add %s to the trusted certificates manually.", CertFilePath())
+			process.Exit(1)
+		}
+	}
+
+	logger.Checkf("CA certificate installed")
+}
+
+func trustStoreCommands() ([][]string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{
+			{"sudo", "security", "add-trusted-cert", "-d", "-k", "/Library/Keychains/System.keychain", CertFilePath()},
+		}, nil
+	case "linux":
+		for _, store := range linuxTrustStores {
+			if fs.FileExists(store.dir) {
+				return [][]string{
+					{"sudo", "cp", CertFilePath(), filepath.Join(store.dir, trustedRootFileName)},
+					append([]string{"sudo"}, store.updateCommand...),
+				}, nil
+			}
+		}
+		return nil, fmt.Errorf("no supported trust store found")
+	default:
+		return nil, fmt.Errorf("%s is not supported", runtime.GOOS)
+	}
+}
@@ -0,0 +1,239 @@
+package capture
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/har"
+	"github.com/jozefcipa/novus/internal/logger"
+)
+
+// Proxy is a small reverse proxy that sits between Nginx and the upstream and records all the traffic
+type Proxy struct {
+	Domain      string
+	Upstream    *url.URL
+	MaxBodySize int64
+
+	listener net.Listener
+	server   *http.Server
+
+	mu      sync.Mutex
+	entries []har.Entry
+	// Called after each recorded request
+	OnEntry func(entry har.Entry)
+}
+
+type exchangeContextKey struct{}
+
+// Holds the data of a single request while it's being proxied
+type exchange struct {
+	startedAt   time.Time
+	requestURL  *url.URL
+	request     *http.Request
+	requestBody *bodyRecorder
+	respondedAt time.Time
+}
+
+func NewProxy(domain string, upstream string, maxBodySize int64) (*Proxy, error) {
+	upstreamURL, err := url.Parse(upstream)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Proxy{
+		Domain:      domain,
+		Upstream:    upstreamURL,
+		MaxBodySize: maxBodySize,
+		entries:     []har.Entry{},
+	}, nil
+}
+
+// Start starts listening on a random local port
+func (p *Proxy) Start() error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	p.listener = listener
+
+	reverseProxy := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(p.Upstream)
+		},
+		ModifyResponse: p.recordResponse,
+		ErrorHandler:   p.recordError,
+		// Stream responses (e.g. server-sent events) immediately
+		FlushInterval: -1,
+	}
+
+	p.server = &http.Server{
+		Handler:           p.wrapRequest(reverseProxy),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := p.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.Errorf("Capture proxy failed: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// Address returns the URL Nginx should proxy the requests to
+func (p *Proxy) Address() string {
+	return "http://" + p.listener.Addr().String()
+}
+
+func (p *Proxy) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	p.server.Shutdown(ctx)
+}
+
+// Entries returns all recorded requests ordered by the time they were started
+func (p *Proxy) Entries() []har.Entry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entries := make([]har.Entry, len(p.entries))
+	copy(entries, p.entries)
+
+	return entries
+}
+
+func (p *Proxy) wrapRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Requests come from Nginx, so the URL has to be reconstructed to match what the client sent
+		requestURL := &url.URL{Scheme: "https", Host: p.Domain, Path: r.URL.Path, RawPath: r.URL.RawPath, RawQuery: r.URL.RawQuery}
+
+		ex := &exchange{
+			startedAt:  time.Now(),
+			requestURL: requestURL,
+			request:    r.Clone(context.Background()),
+		}
+		ex.request.Host = p.Domain
+		ex.request.Header.Set("Host", p.Domain)
+
+		if r.Body != nil && r.Body != http.NoBody {
+			ex.requestBody = newBodyRecorder(r.Body, p.MaxBodySize, nil)
+			r.Body = ex.requestBody
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), exchangeContextKey{}, ex)))
+	})
+}
+
+func (p *Proxy) recordResponse(resp *http.Response) error {
+	ex, ok := resp.Request.Context().Value(exchangeContextKey{}).(*exchange)
+	if !ok {
+		return nil
+	}
+	ex.respondedAt = time.Now()
+
+	// The entry is finished once the whole response body is passed to the client
+	resp.Body = newBodyRecorder(resp.Body, p.MaxBodySize, func(body har.Body) {
+		p.addEntry(ex, har.NewResponse(resp, body))
+	})
+
+	return nil
+}
+
+func (p *Proxy) recordError(w http.ResponseWriter, r *http.Request, err error) {
+	logger.Debugf("Capture proxy failed to reach the upstream: %v", err)
+	w.WriteHeader(http.StatusBadGateway)
+
+	ex, ok := r.Context().Value(exchangeContextKey{}).(*exchange)
+	if !ok {
+		return
+	}
+	ex.respondedAt = time.Now()
+
+	response := har.NewResponse(&http.Response{StatusCode: http.StatusBadGateway, Proto: "HTTP/1.1", Header: http.Header{}}, har.Body{})
+	response.Content.Comment = "Upstream not reachable: " + err.Error()
+	p.addEntry(ex, response)
+}
+
+func (p *Proxy) addEntry(ex *exchange, response har.Response) {
+	finishedAt := time.Now()
+
+	requestBody := har.Body{}
+	if ex.requestBody != nil {
+		requestBody = ex.requestBody.body()
+	}
+
+	entry := har.Entry{
+		StartedDateTime: ex.startedAt,
+		Time:            milliseconds(finishedAt.Sub(ex.startedAt)),
+		Request:         har.NewRequest(ex.request, ex.requestURL, requestBody),
+		Response:        response,
+		Timings: har.Timings{
+			Send:    0,
+			Wait:    milliseconds(ex.respondedAt.Sub(ex.startedAt)),
+			Receive: milliseconds(finishedAt.Sub(ex.respondedAt)),
+		},
+		ServerIPAddress: p.Upstream.Hostname(),
+	}
+
+	p.mu.Lock()
+	p.entries = append(p.entries, entry)
+	p.mu.Unlock()
+
+	if p.OnEntry != nil {
+		p.OnEntry(entry)
+	}
+}
+
+func milliseconds(duration time.Duration) float64 {
+	return float64(duration.Microseconds()) / 1000
+}
+
+// bodyRecorder passes the body through and keeps a copy of its beginning
+type bodyRecorder struct {
+	reader  io.ReadCloser
+	limit   int64
+	data    []byte
+	size    int64
+	onClose func(body har.Body)
+	once    sync.Once
+	mu      sync.Mutex
+}
+
+func newBodyRecorder(reader io.ReadCloser, limit int64, onClose func(body har.Body)) *bodyRecorder {
+	return &bodyRecorder{reader: reader, limit: limit, data: []byte{}, onClose: onClose}
+}
+
+func (b *bodyRecorder) Read(buf []byte) (int, error) {
+	n, err := b.reader.Read(buf)
+
+	b.mu.Lock()
+	b.size += int64(n)
+	if remaining := b.limit - int64(len(b.data)); remaining > 0 {
+		b.data = append(b.data, buf[:min(int64(n), remaining)]...)
+	}
+	b.mu.Unlock()
+
+	return n, err
+}
+
+func (b *bodyRecorder) Close() error {
+	err := b.reader.Close()
+	if b.onClose != nil {
+		b.once.Do(func() { b.onClose(b.body()) })
+	}
+
+	return err
+}
+
+func (b *bodyRecorder) body() har.Body {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return har.Body{Data: b.data, Size: b.size, Truncated: b.size > int64(len(b.data))}
+}
@@ -1,13 +1,13 @@
 package config
 
 import (
-	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/jozefcipa/novus/internal/fs"
 	"github.com/jozefcipa/novus/internal/logger"
 	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/process"
 	"github.com/jozefcipa/novus/internal/sharedtypes"
 	"gopkg.in/yaml.v3"
 )
@@ -34,7 +34,7 @@ func AppName() string {
 	// This should not happen normally,
 	// but let's throw an error if the program tries to access config.AppName() when not set
 	logger.Errorf("[Internal error]: No app set, make sure to call `config.SetAppName()`")
-	os.Exit(1)
+	process.Exit(1)
 	return ""
 }
 
@@ -66,7 +66,7 @@ func LoadFile() (NovusConfig, bool) {
 	err = yaml.Unmarshal([]byte(configFile), &config)
 	if err != nil {
 		logger.Errorf("Failed to parse the config file: %v", err)
-		os.Exit(1)
+		process.Exit(1)
 	}
 
 	return config, true
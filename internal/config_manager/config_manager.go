@@ -2,7 +2,6 @@ package config_manager
 
 import (
 	"fmt"
-	"os"
 	"regexp"
 	"strings"
 
@@ -12,6 +11,7 @@ import (
 	"github.com/jozefcipa/novus/internal/logger"
 	"github.com/jozefcipa/novus/internal/novus"
 	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/process"
 	"github.com/jozefcipa/novus/internal/stringutils"
 	"github.com/jozefcipa/novus/internal/validation"
 )
@@ -75,13 +75,13 @@ func LoadConfigurationFromFile(novusState novus.NovusState) (config.NovusConfig,
 	if exists {
 		if errors := ValidateConfig(conf, ValidationErrorsConfigFile); len(errors) > 0 {
 			logger.Errorf("Configuration file contains errors:\n   %s", strings.Join(errors, "\n   "))
-			os.Exit(1)
+			process.Exit(1)
 		}
 
 		// Validate app name syntax and whether it is unique across apps
 		if err := validateConfigAppName(conf.AppName, novusState); err != nil {
 			logger.Errorf(err.Error())
-			os.Exit(1)
+			process.Exit(1)
 		}
 	}
 
@@ -126,7 +126,7 @@ func ValidateConfigDomainsUniqueness(conf config.NovusConfig, novusState novus.N
 				)
 			}
 		}
-		os.Exit(1)
+		process.Exit(1)
 	}
 }
 
@@ -0,0 +1,115 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/fs"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/paths"
+)
+
+// Daemon runs a Novus subcommand as a detached background process (e.g. `novus agent run`)
+// Its PID and output are stored in the ~/.novus/run directory
+
+func pidFilePath(name string) string {
+	return filepath.Join(paths.NovusRunDir, name+".pid")
+}
+
+func LogFilePath(name string) string {
+	return filepath.Join(paths.NovusRunDir, name+".log")
+}
+
+// Start spawns `novus [args]` in the background unless the daemon is already running
+func Start(name string, args ...string) error {
+	if pid, running := IsRunning(name); running {
+		logger.Debugf("Daemon \"%s\" is already running [pid=%d]", name, pid)
+		return nil
+	}
+
+	fs.MakeDirOrExit(paths.NovusRunDir)
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("Failed to get novus binary path: %v", err)
+	}
+
+	logFile, err := os.OpenFile(LogFilePath(name), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to open log file %s: %v", LogFilePath(name), err)
+	}
+	defer logFile.Close()
+
+	logger.Debugf("Starting daemon \"%s\" [%s %s]", name, executable, strings.Join(args, " "))
+	cmd := exec.Command(executable, args...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	// Detach the process from the current terminal session so it keeps running after Novus exits
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("Failed to start %s: %v", name, err)
+	}
+
+	if err := os.WriteFile(pidFilePath(name), []byte(strconv.Itoa(cmd.Process.Pid)), 0644); err != nil {
+		return fmt.Errorf("Failed to write PID file %s: %v", pidFilePath(name), err)
+	}
+
+	// Give the process a moment to start, so we can detect immediate crashes (e.g. port already in use)
+	time.Sleep(200 * time.Millisecond)
+	if _, running := IsRunning(name); !running {
+		return fmt.Errorf("%s exited right after start, see %s for more details", name, LogFilePath(name))
+	}
+
+	logger.Debugf("Daemon \"%s\" started [pid=%d]", name, cmd.Process.Pid)
+	return cmd.Process.Release()
+}
+
+// Stop terminates the daemon, returns false if it was not running
+func Stop(name string) bool {
+	pid, running := IsRunning(name)
+	defer os.Remove(pidFilePath(name))
+
+	if !running {
+		return false
+	}
+
+	logger.Debugf("Stopping daemon \"%s\" [pid=%d]", name, pid)
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		logger.Debugf("Failed to stop daemon \"%s\": %v", name, err)
+		return false
+	}
+
+	return true
+}
+
+func IsRunning(name string) (int, bool) {
+	content, err := os.ReadFile(pidFilePath(name))
+	if err != nil {
+		return 0, false
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return 0, false
+	}
+
+	// Signal 0 only checks whether the process exists
+	if err := syscall.Kill(pid, syscall.Signal(0)); err != nil {
+		return pid, false
+	}
+
+	// Reap the process if it has already exited (only applies to processes started by us)
+	var status syscall.WaitStatus
+	if waitedPid, _ := syscall.Wait4(pid, &status, syscall.WNOHANG, nil); waitedPid == pid {
+		return pid, false
+	}
+
+	return pid, true
+}
@@ -0,0 +1,363 @@
+package dashboard
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/agent"
+	"github.com/jozefcipa/novus/internal/config"
+	"github.com/jozefcipa/novus/internal/dns_manager"
+	"github.com/jozefcipa/novus/internal/fs"
+	"github.com/jozefcipa/novus/internal/health"
+	"github.com/jozefcipa/novus/internal/maputils"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/proxy_manager"
+	"github.com/jozefcipa/novus/internal/request_log"
+	"github.com/jozefcipa/novus/internal/subcommand"
+	"golang.org/x/term"
+)
+
+// How often the state, services and upstreams are checked
+const refreshInterval = 3 * time.Second
+
+// How much of the request log is read to show recent requests
+const requestLogTailBytes = 256 * 1024
+
+type snapshot struct {
+	state        novus.NovusState
+	proxyRunning bool
+	dnsRunning   bool
+	agentRunning bool
+	health       map[string]*health.UpstreamHealth
+	requests     map[string][]request_log.Entry // app name -> recent requests
+	refreshedAt  time.Time
+	// Set if the state file can't be read, the last valid state is shown instead
+	stateErr error
+}
+
+type dashboard struct {
+	mu sync.Mutex
+
+	data     snapshot
+	loaded   bool
+	selected string
+
+	// Action waiting for a confirmation (e.g. removing an app)
+	confirmation *action
+	busy         bool
+	message      string
+	messageIsErr bool
+
+	redraw chan struct{}
+}
+
+type action struct {
+	label string
+	dir   string
+	args  []string
+}
+
+// Run opens a full-screen dashboard that refreshes periodically until the user quits it
+func Run() error {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return errors.New("The dashboard must be run in an interactive terminal")
+	}
+
+	oldTermState, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("Failed to initialize the terminal: %v", err)
+	}
+
+	// Switch to the alternate screen and hide the cursor
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	restoreTerminal := func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		term.Restore(fd, oldTermState)
+	}
+	defer restoreTerminal()
+	// Make sure the terminal is usable again even if some check fails and exits the program
+	process.OnFailure(restoreTerminal)
+
+	d := &dashboard{redraw: make(chan struct{}, 1)}
+
+	keys := make(chan []byte)
+	go readKeys(keys)
+
+	resize := make(chan os.Signal, 1)
+	signal.Notify(resize, syscall.SIGWINCH)
+
+	go d.refreshPeriodically()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	d.render()
+	for {
+		select {
+		case key := <-keys:
+			if quit := d.handleKey(key); quit {
+				return nil
+			}
+		case <-resize:
+		case <-ticker.C:
+		case <-d.redraw:
+		}
+		d.render()
+	}
+}
+
+func readKeys(keys chan<- []byte) {
+	buf := make([]byte, 16)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+		key := make([]byte, n)
+		copy(key, buf[:n])
+		keys <- key
+	}
+}
+
+func (d *dashboard) requestRedraw() {
+	select {
+	case d.redraw <- struct{}{}:
+	default:
+	}
+}
+
+func (d *dashboard) refreshPeriodically() {
+	for {
+		data := loadSnapshot()
+
+		d.mu.Lock()
+		d.setData(data)
+		d.loaded = true
+		// Keep the selection valid
+		appNames := userAppNames(data.state)
+		if !slices.Contains(appNames, d.selected) {
+			d.selected = ""
+			if len(appNames) > 0 {
+				d.selected = appNames[0]
+			}
+		}
+		d.mu.Unlock()
+
+		d.requestRedraw()
+		time.Sleep(refreshInterval)
+	}
+}
+
+// State is reloaded both periodically and after each action
+var stateMutex sync.Mutex
+
+func loadSnapshot() snapshot {
+	stateMutex.Lock()
+	reloadedState, err := novus.TryReloadState()
+	novusState := *reloadedState
+	stateMutex.Unlock()
+
+	data := snapshot{
+		state:       novusState,
+		stateErr:    err,
+		health:      map[string]*health.UpstreamHealth{},
+		refreshedAt: time.Now(),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		data.proxyRunning = proxy_manager.IsRunning()
+	}()
+	go func() {
+		defer wg.Done()
+		data.dnsRunning = dns_manager.IsRunning()
+	}()
+	go func() {
+		defer wg.Done()
+		for _, result := range health.CheckActiveRoutes(novusState) {
+			data.health[result.Domain] = result
+		}
+	}()
+	data.agentRunning = agent.IsRunning()
+	data.requests = map[string][]request_log.Entry{}
+	for _, appName := range userAppNames(novusState) {
+		data.requests[appName] = request_log.Tail(paths.AppAccessLogFilePath(appName), requestLogTailBytes)
+	}
+	wg.Wait()
+
+	return data
+}
+
+// Returns all apps except the internal one, sorted by name
+func userAppNames(novusState novus.NovusState) []string {
+	appNames := slices.DeleteFunc(maputils.MapKeys(novusState.Apps), func(appName string) bool {
+		return appName == novus.NovusInternalAppName
+	})
+	slices.SortFunc(appNames, func(a, b string) int { return cmp.Compare(a, b) })
+
+	return appNames
+}
+
+// Handles a key press, returns true if the dashboard should be closed
+func (d *dashboard) handleKey(key []byte) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Confirmation prompt takes precedence over other keys
+	if d.confirmation != nil {
+		confirmed := string(key) == "y" || string(key) == "Y"
+		pending := d.confirmation
+		d.confirmation = nil
+		if confirmed {
+			d.runAction(*pending)
+		} else {
+			d.setMessage("Cancelled", false)
+		}
+		return false
+	}
+
+	switch string(key) {
+	case "q", "\x03": // q, Ctrl+C
+		return true
+	case "\x1b[A", "k":
+		d.moveSelection(-1)
+	case "\x1b[B", "j":
+		d.moveSelection(1)
+	case "p":
+		d.togglePause()
+	case "r":
+		d.remove()
+	case "s":
+		d.serve()
+	}
+
+	return false
+}
+
+func (d *dashboard) moveSelection(offset int) {
+	appNames := userAppNames(d.data.state)
+	idx := slices.Index(appNames, d.selected)
+	if idx == -1 || len(appNames) == 0 {
+		return
+	}
+
+	d.selected = appNames[max(0, min(len(appNames)-1, idx+offset))]
+}
+
+func (d *dashboard) selectedApp() (*novus.AppState, bool) {
+	appState, exists := d.data.state.Apps[d.selected]
+	if !exists {
+		return nil, false
+	}
+
+	if d.busy {
+		d.setMessage("Another action is still running, please wait", true)
+		return nil, false
+	}
+
+	if d.selected == novus.GlobalAppName {
+		d.setMessage("Global routes cannot be managed from the dashboard, use \"novus serve\" or \"novus remove\"", true)
+		return nil, false
+	}
+
+	return appState, true
+}
+
+func (d *dashboard) togglePause() {
+	appState, ok := d.selectedApp()
+	if !ok {
+		return
+	}
+
+	if appState.Status == novus.APP_ACTIVE {
+		d.runAction(action{label: "Pausing " + d.selected, args: []string{"pause", d.selected}})
+	} else {
+		d.runAction(action{label: "Resuming " + d.selected, args: []string{"resume", d.selected}})
+	}
+}
+
+func (d *dashboard) remove() {
+	if _, ok := d.selectedApp(); !ok {
+		return
+	}
+
+	d.confirmation = &action{label: "Removing " + d.selected, args: []string{"remove", d.selected, "--yes"}}
+	d.setMessage(fmt.Sprintf("Do you really want to remove \"%s\"? [y/N]", d.selected), false)
+}
+
+func (d *dashboard) serve() {
+	appState, ok := d.selectedApp()
+	if !ok {
+		return
+	}
+
+	if !fs.FileExists(filepath.Join(appState.Directory, config.ConfigFileName)) {
+		d.setMessage(fmt.Sprintf("%s not found in %s", config.ConfigFileName, appState.Directory), true)
+		return
+	}
+
+	d.runAction(action{label: "Serving " + d.selected, dir: appState.Directory, args: []string{"serve"}})
+}
+
+// Runs the Novus command in the background, must be called with the lock held
+func (d *dashboard) runAction(a action) {
+	d.busy = true
+	d.setMessage(a.label+"...", false)
+
+	go func() {
+		output, err := subcommand.Run(a.dir, a.args...)
+
+		d.mu.Lock()
+		d.busy = false
+		if err != nil {
+			d.setMessage(fmt.Sprintf("%s failed: %s", a.label, lastLine(output, err)), true)
+		} else {
+			d.setMessage(fmt.Sprintf("%s done", a.label), false)
+		}
+		d.mu.Unlock()
+
+		// Show the changes immediately
+		data := loadSnapshot()
+		d.mu.Lock()
+		d.setData(data)
+		d.mu.Unlock()
+		d.requestRedraw()
+	}()
+}
+
+// Must be called with the lock held
+func (d *dashboard) setData(data snapshot) {
+	d.data = data
+	if data.stateErr != nil {
+		d.setMessage(fmt.Sprintf("Failed to read the state file, run \"novus state restore\" to fix it: %v", data.stateErr), true)
+	}
+}
+
+func (d *dashboard) setMessage(message string, isErr bool) {
+	d.message = message
+	d.messageIsErr = isErr
+}
+
+// Returns the last line of the command output which usually contains the error message
+func lastLine(output string, err error) string {
+	if strings.TrimSpace(output) == "" {
+		return err.Error()
+	}
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return lines[len(lines)-1]
+}
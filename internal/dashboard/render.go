@@ -0,0 +1,281 @@
+package dashboard
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jozefcipa/novus/internal/dns_manager"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/proxy_manager"
+	"github.com/jozefcipa/novus/internal/request_log"
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+)
+
+const (
+	BOLD    = "\033[1m"
+	REVERSE = "\033[7m"
+)
+
+var ansiEscapeRegex = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+type routeRow struct {
+	appName string
+	line    string
+}
+
+func (d *dashboard) render() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width < 40 || height < 12 {
+		width, height = max(width, 80), max(height, 24)
+	}
+
+	lines := []string{}
+	lines = append(lines, d.renderHeader(width), "")
+
+	if !d.loaded {
+		lines = append(lines, logger.GRAY+"  Loading..."+logger.RESET)
+	} else {
+		// Split the screen between the routes table and recent requests
+		available := height - len(lines) - 4 // table header, requests header (2 lines), footer
+		routeRows := d.renderRouteRows()
+		routeLines := min(len(routeRows), max(available*3/5, available-len(d.selectedRequests())))
+		requestLines := available - routeLines
+
+		lines = append(lines, fmt.Sprintf("%s  %-21s %-8s %-34s %-28s %s%s", BOLD, "APP", "STATUS", "DOMAIN", "UPSTREAM", "HEALTH", logger.RESET))
+		lines = append(lines, scrollToSelection(routeRows, d.selected, routeLines)...)
+		for i := len(routeRows); i < routeLines; i++ {
+			lines = append(lines, "")
+		}
+
+		lines = append(lines, "", fmt.Sprintf("%s  Recent requests · %s%s", BOLD, displayAppName(d.selected), logger.RESET))
+		lines = append(lines, d.renderRequests(requestLines)...)
+	}
+
+	// Fill the rest of the screen so the footer is always at the bottom
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines[:height-1], d.renderFooter())
+
+	var out strings.Builder
+	out.WriteString("\x1b[H")
+	for i, line := range lines {
+		out.WriteString(truncate(line, width))
+		// Clear the rest of the line
+		out.WriteString("\x1b[K")
+		if i < len(lines)-1 {
+			// Terminal is in raw mode, so carriage return is needed as well
+			out.WriteString("\r\n")
+		}
+	}
+	fmt.Print(out.String())
+}
+
+func (d *dashboard) renderHeader(width int) string {
+	services := strings.Join([]string{
+		formatService(proxy_manager.ServerName(), d.data.proxyRunning),
+		formatService(dns_manager.ServerName(), d.data.dnsRunning),
+		formatService("Agent", d.data.agentRunning),
+	}, "   ")
+
+	refreshedAt := ""
+	if d.loaded {
+		refreshedAt = logger.GRAY + d.data.refreshedAt.Format("15:04:05") + logger.RESET
+	}
+
+	title := BOLD + logger.CYAN + "  Novus dashboard" + logger.RESET
+	right := services + "   " + refreshedAt
+	padding := max(1, width-visibleWidth(title)-visibleWidth(right)-1)
+
+	return title + strings.Repeat(" ", padding) + right
+}
+
+func formatService(name string, running bool) string {
+	if running {
+		return logger.GREEN + "● " + logger.RESET + name
+	}
+	return logger.RED + "● " + logger.RESET + name
+}
+
+func (d *dashboard) renderRouteRows() []routeRow {
+	rows := []routeRow{}
+
+	for _, appName := range userAppNames(d.data.state) {
+		appState := d.data.state.Apps[appName]
+		isSelected := appName == d.selected
+
+		statusColor := logger.GREEN
+		if appState.Status == novus.APP_PAUSED {
+			statusColor = logger.YELLOW
+		}
+
+		if len(appState.Routes) == 0 {
+			rows = append(rows, routeRow{appName: appName, line: formatAppCell(appName, isSelected)})
+			continue
+		}
+
+		for i, route := range appState.Routes {
+			appCell := strings.Repeat(" ", 24)
+			statusCell := strings.Repeat(" ", 9)
+			if i == 0 {
+				appCell = formatAppCell(appName, isSelected)
+				statusCell = statusColor + pad(strings.ToUpper(string(appState.Status)), 9) + logger.RESET
+			}
+
+			rows = append(rows, routeRow{
+				appName: appName,
+				line: appCell + statusCell +
+					pad("https://"+route.Domain, 35) +
+					logger.GRAY + pad(route.Upstream, 29) + logger.RESET +
+					d.formatHealth(route.Domain, appState.Status),
+			})
+		}
+	}
+
+	return rows
+}
+
+func formatAppCell(appName string, isSelected bool) string {
+	if isSelected {
+		return REVERSE + BOLD + pad("> "+displayAppName(appName), 23) + logger.RESET + " "
+	}
+	return logger.CYAN + pad("  "+displayAppName(appName), 23) + logger.RESET + " "
+}
+
+func displayAppName(appName string) string {
+	if appName == novus.GlobalAppName {
+		return "Global Routes"
+	}
+	return appName
+}
+
+func (d *dashboard) formatHealth(domain string, status novus.AppStatus) string {
+	result, exists := d.data.health[domain]
+	if status != novus.APP_ACTIVE || !exists {
+		return logger.GRAY + "-" + logger.RESET
+	}
+
+	if result.Healthy {
+		return fmt.Sprintf("%s●%s %dms", logger.GREEN, logger.RESET, result.LatencyMs)
+	}
+	return logger.RED + "● down" + logger.RESET
+}
+
+// Keeps the selected app visible if there are more rows than lines available
+func scrollToSelection(rows []routeRow, selected string, limit int) []string {
+	start := 0
+	if len(rows) > limit {
+		selectedIdx := slices.IndexFunc(rows, func(row routeRow) bool { return row.appName == selected })
+		start = max(0, min(selectedIdx, len(rows)-limit))
+	}
+
+	lines := []string{}
+	for _, row := range rows[start:min(len(rows), start+limit)] {
+		lines = append(lines, row.line)
+	}
+
+	return lines
+}
+
+func (d *dashboard) selectedRequests() []request_log.Entry {
+	return d.data.requests[d.selected]
+}
+
+func (d *dashboard) renderRequests(limit int) []string {
+	requests := d.selectedRequests()
+	if len(requests) == 0 {
+		return []string{logger.GRAY + "  No requests yet" + logger.RESET}
+	}
+
+	// Show the most recent requests
+	requests = requests[max(0, len(requests)-limit):]
+
+	lines := []string{}
+	for _, entry := range requests {
+		lines = append(lines, fmt.Sprintf(
+			"  %s%s%s  %-7s %s  %-28s %s %s%6.0fms%s",
+			logger.GRAY, entry.Time.Local().Format("15:04:05"), logger.RESET,
+			entry.Method,
+			formatStatus(entry.Status),
+			entry.Host,
+			entry.URI,
+			logger.GRAY, entry.Duration*1000, logger.RESET,
+		))
+	}
+
+	return lines
+}
+
+func formatStatus(status int) string {
+	color := logger.GREEN
+	switch {
+	case status >= 500:
+		color = logger.RED
+	case status >= 400:
+		color = logger.ORANGE
+	case status >= 300:
+		color = logger.CYAN
+	}
+
+	return fmt.Sprintf("%s%d%s", color, status, logger.RESET)
+}
+
+func (d *dashboard) renderFooter() string {
+	keys := logger.GRAY + "  [↑/↓] select  [p] pause/resume  [s] serve  [r] remove  [q] quit" + logger.RESET
+
+	if d.message == "" {
+		return keys
+	}
+
+	color := logger.WHITE
+	if d.messageIsErr {
+		color = logger.RED
+	}
+	return keys + "   " + color + d.message + logger.RESET
+}
+
+func visibleWidth(s string) int {
+	return runewidth.StringWidth(ansiEscapeRegex.ReplaceAllString(s, ""))
+}
+
+// Pads (or truncates) the text to the given width
+func pad(s string, width int) string {
+	s = runewidth.Truncate(s, width-1, "…")
+	return s + strings.Repeat(" ", width-runewidth.StringWidth(s))
+}
+
+// Truncates a line containing color codes to the terminal width
+func truncate(line string, width int) string {
+	if visibleWidth(line) <= width {
+		return line
+	}
+
+	var out strings.Builder
+	visible := 0
+	for i := 0; i < len(line); {
+		if loc := ansiEscapeRegex.FindStringIndex(line[i:]); loc != nil && loc[0] == 0 {
+			out.WriteString(line[i : i+loc[1]])
+			i += loc[1]
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if visible+runewidth.RuneWidth(r) > width {
+			break
+		}
+		visible += runewidth.RuneWidth(r)
+		out.WriteRune(r)
+		i += size
+	}
+
+	return out.String() + logger.RESET
+}
@@ -10,16 +10,21 @@ import (
 )
 
 func routeExists(domain string, routes []sharedtypes.Route) bool {
+	_, exists := findRoute(domain, routes)
+	return exists
+}
+
+func findRoute(domain string, routes []sharedtypes.Route) (sharedtypes.Route, bool) {
 	for _, route := range routes {
 		if route.Domain == domain {
-			return true
+			return route, true
 		}
 	}
 
-	return false
+	return sharedtypes.Route{}, false
 }
 
-func DetectConfigDiff(conf config.NovusConfig, state novus.AppState) (added []sharedtypes.Route, deleted []sharedtypes.Route) {
+func DetectConfigDiff(conf config.NovusConfig, state novus.AppState) (added []sharedtypes.Route, deleted []sharedtypes.Route, modified []ModifiedRoute) {
 	// Detect routes that are stored in state but have been removed from the configuration file
 	for _, route := range state.Routes {
 		if !routeExists(route.Domain, conf.Routes) {
@@ -27,14 +32,32 @@ func DetectConfigDiff(conf config.NovusConfig, state novus.AppState) (added []sh
 		}
 	}
 
-	// Detect routes that are found in configuration file but are not stored in the state
+	// Detect routes that are found in configuration file but are not stored in the state,
+	// or routes that exist in both but their definition (e.g. upstream) has changed
 	for _, route := range conf.Routes {
-		if !routeExists(route.Domain, state.Routes) {
+		stateRoute, exists := findRoute(route.Domain, state.Routes)
+		if !exists {
 			added = append(added, route)
+			continue
+		}
+
+		if changes := detectRouteChanges(stateRoute, route); len(changes) > 0 {
+			modified = append(modified, ModifiedRoute{
+				Domain:  route.Domain,
+				Old:     stateRoute,
+				New:     route,
+				Changes: changes,
+			})
 		}
 	}
 
-	return added, deleted
+	return added, deleted, modified
+}
+
+// Any added, deleted or modified route changes the routing rules, so the proxy must reload.
+// DNS only resolves the domains, it needs a restart only when the registered TLDs change (see dns_manager.Configure)
+func RoutesChanged(added []sharedtypes.Route, deleted []sharedtypes.Route, modified []ModifiedRoute) bool {
+	return len(added) > 0 || len(deleted) > 0 || len(modified) > 0
 }
 
 func DetectUnusedTLDs(deletedRoutes []sharedtypes.Route, stateRoutes []sharedtypes.Route) []string {
@@ -0,0 +1,198 @@
+package diff_manager
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jozefcipa/novus/internal/config"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/sharedtypes"
+)
+
+func TestDetectConfigDiff(t *testing.T) {
+	api := sharedtypes.Route{Domain: "api.test", Upstream: "http://localhost:4000"}
+	web := sharedtypes.Route{Domain: "web.test", Upstream: "http://localhost:3000"}
+
+	tests := []struct {
+		name         string
+		configRoutes []sharedtypes.Route
+		stateRoutes  []sharedtypes.Route
+		wantAdded    []sharedtypes.Route
+		wantDeleted  []sharedtypes.Route
+		wantModified []string
+		wantChanged  bool
+	}{
+		{
+			name:         "unchanged route",
+			configRoutes: []sharedtypes.Route{api},
+			stateRoutes:  []sharedtypes.Route{api},
+		},
+		{
+			name:         "added route",
+			configRoutes: []sharedtypes.Route{api, web},
+			stateRoutes:  []sharedtypes.Route{api},
+			wantAdded:    []sharedtypes.Route{web},
+			wantChanged:  true,
+		},
+		{
+			name:         "removed route",
+			configRoutes: []sharedtypes.Route{api},
+			stateRoutes:  []sharedtypes.Route{api, web},
+			wantDeleted:  []sharedtypes.Route{web},
+			wantChanged:  true,
+		},
+		{
+			name:         "upstream change",
+			configRoutes: []sharedtypes.Route{{Domain: "api.test", Upstream: "http://localhost:4001"}},
+			stateRoutes:  []sharedtypes.Route{api},
+			wantModified: []string{"api.test: upstream :4000 → :4001"},
+			wantChanged:  true,
+		},
+		{
+			name:         "CORS toggle",
+			configRoutes: []sharedtypes.Route{{Domain: "api.test", Upstream: "http://localhost:4000", Cors: true}},
+			stateRoutes:  []sharedtypes.Route{api},
+			wantModified: []string{"api.test: cors off → on"},
+			wantChanged:  true,
+		},
+		{
+			name:         "upstream change and CORS toggle",
+			configRoutes: []sharedtypes.Route{{Domain: "api.test", Upstream: "http://localhost:4001", Cors: true}},
+			stateRoutes:  []sharedtypes.Route{api},
+			wantModified: []string{"api.test: upstream :4000 → :4001, cors off → on"},
+			wantChanged:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := config.NovusConfig{AppName: "app", Routes: tt.configRoutes}
+			state := novus.AppState{Routes: tt.stateRoutes}
+
+			added, deleted, modified := DetectConfigDiff(conf, state)
+
+			if !reflect.DeepEqual(added, tt.wantAdded) {
+				t.Errorf("Added routes = %v, want %v", added, tt.wantAdded)
+			}
+			if !reflect.DeepEqual(deleted, tt.wantDeleted) {
+				t.Errorf("Deleted routes = %v, want %v", deleted, tt.wantDeleted)
+			}
+
+			var modifiedDescriptions []string
+			for _, route := range modified {
+				modifiedDescriptions = append(modifiedDescriptions, route.String())
+			}
+			if !reflect.DeepEqual(modifiedDescriptions, tt.wantModified) {
+				t.Errorf("Modified routes = %v, want %v", modifiedDescriptions, tt.wantModified)
+			}
+
+			if changed := RoutesChanged(added, deleted, modified); changed != tt.wantChanged {
+				t.Errorf("RoutesChanged() = %t, want %t", changed, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestDetectRouteChanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldRoute sharedtypes.Route
+		newRoute sharedtypes.Route
+		want     []FieldChange
+	}{
+		{
+			name:     "unchanged route",
+			oldRoute: sharedtypes.Route{Domain: "api.test", Upstream: "http://localhost:4000", Cors: true},
+			newRoute: sharedtypes.Route{Domain: "api.test", Upstream: "http://localhost:4000", Cors: true},
+			want:     []FieldChange{},
+		},
+		{
+			name:     "upstream change",
+			oldRoute: sharedtypes.Route{Domain: "api.test", Upstream: "http://localhost:4000"},
+			newRoute: sharedtypes.Route{Domain: "api.test", Upstream: "http://localhost:4001"},
+			want:     []FieldChange{{Field: "upstream", Old: ":4000", New: ":4001"}},
+		},
+		{
+			name:     "CORS enabled",
+			oldRoute: sharedtypes.Route{Domain: "api.test", Upstream: "http://localhost:4000"},
+			newRoute: sharedtypes.Route{Domain: "api.test", Upstream: "http://localhost:4000", Cors: true},
+			want:     []FieldChange{{Field: "cors", Old: "off", New: "on"}},
+		},
+		{
+			name:     "CORS disabled",
+			oldRoute: sharedtypes.Route{Domain: "api.test", Upstream: "http://localhost:4000", Cors: true},
+			newRoute: sharedtypes.Route{Domain: "api.test", Upstream: "http://localhost:4000"},
+			want:     []FieldChange{{Field: "cors", Old: "on", New: "off"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if changes := detectRouteChanges(tt.oldRoute, tt.newRoute); !reflect.DeepEqual(changes, tt.want) {
+				t.Errorf("detectRouteChanges() = %v, want %v", changes, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatUpstreamChange(t *testing.T) {
+	tests := []struct {
+		name        string
+		oldUpstream string
+		newUpstream string
+		wantOld     string
+		wantNew     string
+	}{
+		{
+			name:        "port change",
+			oldUpstream: "http://localhost:4000",
+			newUpstream: "http://localhost:4001",
+			wantOld:     ":4000",
+			wantNew:     ":4001",
+		},
+		{
+			name:        "port change with path",
+			oldUpstream: "http://localhost:4000/api",
+			newUpstream: "http://localhost:4001/api",
+			wantOld:     ":4000",
+			wantNew:     ":4001",
+		},
+		{
+			name:        "host change",
+			oldUpstream: "http://localhost:4000",
+			newUpstream: "http://127.0.0.1:4000",
+			wantOld:     "http://localhost:4000",
+			wantNew:     "http://127.0.0.1:4000",
+		},
+		{
+			name:        "path change",
+			oldUpstream: "http://localhost:4000/v1",
+			newUpstream: "http://localhost:4001/v2",
+			wantOld:     "http://localhost:4000/v1",
+			wantNew:     "http://localhost:4001/v2",
+		},
+		{
+			name:        "port added",
+			oldUpstream: "http://localhost",
+			newUpstream: "http://localhost:4000",
+			wantOld:     "http://localhost",
+			wantNew:     "http://localhost:4000",
+		},
+		{
+			name:        "invalid upstream",
+			oldUpstream: "http://localhost:4000",
+			newUpstream: "http://[::1",
+			wantOld:     "http://localhost:4000",
+			wantNew:     "http://[::1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldUpstream, newUpstream := formatUpstreamChange(tt.oldUpstream, tt.newUpstream)
+			if oldUpstream != tt.wantOld || newUpstream != tt.wantNew {
+				t.Errorf("formatUpstreamChange() = (%s, %s), want (%s, %s)", oldUpstream, newUpstream, tt.wantOld, tt.wantNew)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package diff_manager
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jozefcipa/novus/internal/sharedtypes"
+)
+
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+type ModifiedRoute struct {
+	Domain  string
+	Old     sharedtypes.Route
+	New     sharedtypes.Route
+	Changes []FieldChange
+}
+
+// Returns a human readable description of the change, e.g. "api.test: upstream :4000 → :4001, cors off → on"
+func (r ModifiedRoute) String() string {
+	changes := make([]string, 0, len(r.Changes))
+	for _, change := range r.Changes {
+		changes = append(changes, fmt.Sprintf("%s %s → %s", change.Field, change.Old, change.New))
+	}
+
+	return fmt.Sprintf("%s: %s", r.Domain, strings.Join(changes, ", "))
+}
+
+func detectRouteChanges(oldRoute sharedtypes.Route, newRoute sharedtypes.Route) []FieldChange {
+	changes := []FieldChange{}
+
+	if oldRoute.Upstream != newRoute.Upstream {
+		oldUpstream, newUpstream := formatUpstreamChange(oldRoute.Upstream, newRoute.Upstream)
+		changes = append(changes, FieldChange{Field: "upstream", Old: oldUpstream, New: newUpstream})
+	}
+
+	if oldRoute.Cors != newRoute.Cors {
+		changes = append(changes, FieldChange{Field: "cors", Old: formatToggle(oldRoute.Cors), New: formatToggle(newRoute.Cors)})
+	}
+
+	return changes
+}
+
+// If only the port has changed, display just the ports (e.g. ":4000 → :4001"),
+// otherwise display the full upstream addresses
+func formatUpstreamChange(oldUpstream string, newUpstream string) (string, string) {
+	oldURL, oldErr := url.Parse(oldUpstream)
+	newURL, newErr := url.Parse(newUpstream)
+	if oldErr != nil || newErr != nil {
+		return oldUpstream, newUpstream
+	}
+
+	if oldURL.Scheme == newURL.Scheme &&
+		oldURL.Hostname() == newURL.Hostname() &&
+		oldURL.Path == newURL.Path &&
+		oldURL.Port() != "" &&
+		newURL.Port() != "" {
+		return ":" + oldURL.Port(), ":" + newURL.Port()
+	}
+
+	return oldUpstream, newUpstream
+}
+
+func formatToggle(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
@@ -2,20 +2,25 @@ package dns_manager
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
+	"runtime"
 
 	"github.com/jozefcipa/novus/internal/config"
+	"github.com/jozefcipa/novus/internal/dns_server"
 	"github.com/jozefcipa/novus/internal/dnsmasq"
+	"github.com/jozefcipa/novus/internal/dry_run"
 	"github.com/jozefcipa/novus/internal/fs"
 	"github.com/jozefcipa/novus/internal/logger"
 	"github.com/jozefcipa/novus/internal/maputils"
 	"github.com/jozefcipa/novus/internal/novus"
 	"github.com/jozefcipa/novus/internal/paths"
 	"github.com/jozefcipa/novus/internal/ports"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/service_manager"
+	"github.com/jozefcipa/novus/internal/settings"
 	"github.com/jozefcipa/novus/internal/sharedtypes"
 	"github.com/jozefcipa/novus/internal/sudo"
 	"github.com/jozefcipa/novus/internal/tld"
+	"github.com/jozefcipa/novus/internal/transaction"
 	"github.com/jozefcipa/novus/internal/tui"
 )
 
@@ -42,12 +47,15 @@ func GetTLDs(routes []sharedtypes.Route) []string {
 func Configure(config config.NovusConfig, novusState *novus.NovusState) bool {
 	dnsPort := GetDNSPort(novusState)
 
-	// Update main DNSMasq configuration
-	updated := dnsmasq.Configure(dnsPort)
+	// Update main DNSMasq configuration (the built-in server reads the routes directly from the state)
+	updated := false
+	if !settings.UseBuiltinDNS() {
+		updated = dnsmasq.Configure(dnsPort)
+	}
 
 	// Create the DNS resolver directory if not exists
-	// https://www.manpagez.com/man/5/resolver/
 	sudo.MakeDirOrExit(paths.DNSResolverDir)
+	resolversUpdated := false
 
 	// Create configs for each TLD
 	tlds := GetTLDs(config.Routes)
@@ -55,28 +63,36 @@ func Configure(config config.NovusConfig, novusState *novus.NovusState) bool {
 	tlds = append(tlds, GetTLDs(novusState.Apps[novus.NovusInternalAppName].Routes)...)
 
 	for _, tld := range tlds {
-		configCreated, configPath := dnsmasq.CreateTLDConfig(tld)
-
 		// Initialize state struct if not exists
 		if _, exists := novusState.DnsFiles[tld]; !exists {
 			novusState.DnsFiles[tld] = &novus.DnsFiles{}
+			// The built-in DNS server answers all TLDs registered in the state, so it needs a restart
+			updated = true
 		}
 
-		if configCreated {
-			updated = true
-			// Store config path in state
-			novusState.DnsFiles[tld].DnsMasqConfig = configPath
+		if !settings.UseBuiltinDNS() {
+			configCreated, configPath := dnsmasq.CreateTLDConfig(tld)
+			if configCreated {
+				updated = true
+				// Store config path in state
+				novusState.DnsFiles[tld].DnsMasqConfig = configPath
+			}
 		}
 
 		// Register the system's DNS TLD resolver
 		resolverCreated, resolverPath := registerTLDResolver(tld, dnsPort)
 		if resolverCreated {
 			updated = true
+			resolversUpdated = true
 			// Store config path in state
 			novusState.DnsFiles[tld].DnsResolver = resolverPath
 		}
 	}
 
+	if resolversUpdated {
+		restartSystemResolver()
+	}
+
 	if updated {
 		logger.Checkf("DNS configuration updated")
 		return true
@@ -87,7 +103,7 @@ func Configure(config config.NovusConfig, novusState *novus.NovusState) bool {
 }
 
 func registerTLDResolver(tld string, dnsPort string) (bool, string) {
-	configPath := filepath.Join(paths.DNSResolverDir, tld)
+	configPath := paths.DNSResolverFilePath(tld)
 
 	// First check if the file already exists (but only if the port was not changed)
 	if !dnsPortUpdated {
@@ -100,32 +116,110 @@ func registerTLDResolver(tld string, dnsPort string) (bool, string) {
 	logger.Debugf("Creating/updating DNS resolver [*.%s] (DNS port: %s)", tld, dnsPort)
 
 	// Create a configuration file
-	configContent := fmt.Sprintf("nameserver 127.0.0.1\nport %s\n", dnsPort)
+	configContent := resolverConfig(tld, dnsPort)
+	transaction.TrackPrivilegedFile(configPath)
 	sudo.WriteFileOrExit(configPath, configContent)
 	logger.Debugf("DNS resolver for *.%s saved [%s]", tld, configPath)
 
 	return true, configPath
 }
 
+func resolverConfig(tld string, dnsPort string) string {
+	if runtime.GOOS == "linux" {
+		// Route only the queries for the TLD to DNSMasq
+		// https://www.freedesktop.org/software/systemd/man/latest/resolved.conf.html
+		return fmt.Sprintf("[Resolve]\nDNS=127.0.0.1:%s\nDomains=~%s\n", dnsPort, tld)
+	}
+
+	// https://www.manpagez.com/man/5/resolver/
+	return fmt.Sprintf("nameserver 127.0.0.1\nport %s\n", dnsPort)
+}
+
+// systemd-resolved only reads the drop-ins when started, macOS picks up the /etc/resolver changes automatically
+func restartSystemResolver() {
+	if runtime.GOOS != "linux" {
+		return
+	}
+
+	if dry_run.Enabled {
+		dry_run.Record(dry_run.Action{Type: dry_run.RestartService, Target: "systemd-resolved"})
+		return
+	}
+
+	// If anything fails later on, the resolver needs to be restarted again to load the restored drop-ins
+	transaction.OnRollback("systemd-resolved", restartSystemResolver)
+
+	loader := logger.Loadingf("Restarting systemd-resolved")
+	service_manager.Restart("systemd-resolved")
+	loader.Checkf("systemd-resolved restarted")
+}
+
 func UnregisterTLD(tld string, novusState *novus.NovusState) {
+	if dry_run.Enabled {
+		dry_run.Record(dry_run.Action{Type: dry_run.UnregisterTLD, Target: "*." + tld})
+	}
+
 	if novusState.DnsFiles[tld].DnsMasqConfig != "" {
 		logger.Debugf("Deleting DNSMasq configuration for *.%s [%s]", tld, novusState.DnsFiles[tld].DnsMasqConfig)
+		transaction.TrackFile(novusState.DnsFiles[tld].DnsMasqConfig)
 		fs.DeleteFile(novusState.DnsFiles[tld].DnsMasqConfig)
 	}
 
 	if novusState.DnsFiles[tld].DnsResolver != "" {
 		logger.Infof("Deleting DNS resolver for *.%s", tld)
 		logger.Debugf("*.%s resolver saved in %s", tld, novusState.DnsFiles[tld].DnsResolver)
+		transaction.TrackPrivilegedFile(novusState.DnsFiles[tld].DnsResolver)
 		err := sudo.DeleteFile(novusState.DnsFiles[tld].DnsResolver)
 		if err != nil {
 			logger.Debugf(err.Error())
 		}
+		restartSystemResolver()
 	}
 
 	// Remove from state
 	delete(novusState.DnsFiles, tld)
 }
 
+// Name of the DNS server selected in the settings, shown to the user
+func ServerName() string {
+	if settings.UseBuiltinDNS() {
+		return "Novus DNS"
+	}
+	return "DNSMasq"
+}
+
+// Name of the process listening on the DNS port (as reported by lsof)
+func dnsProcessName() string {
+	if settings.UseBuiltinDNS() {
+		return "novus"
+	}
+	return "dnsmasq"
+}
+
+// Restart restarts the DNS server selected in the settings (DNSMasq or the built-in server)
+func Restart() {
+	if settings.UseBuiltinDNS() {
+		dns_server.Restart(GetDNSPort(novus.GetState()))
+		return
+	}
+	dnsmasq.Restart()
+}
+
+func Stop() {
+	if settings.UseBuiltinDNS() {
+		dns_server.Stop()
+		return
+	}
+	dnsmasq.Stop()
+}
+
+func IsRunning() bool {
+	if settings.UseBuiltinDNS() {
+		return dns_server.IsRunning()
+	}
+	return dnsmasq.IsRunning()
+}
+
 func GetDNSPort(novusState *novus.NovusState) string {
 	if novusState.DNSMasq.Port != "" && novusState.DNSMasq.Port != dnsmasq.DefaultPort {
 		logger.Debugf("Using custom DNS port from state: %s", novusState.DNSMasq.Port)
@@ -139,8 +233,8 @@ func EnsurePort(initialPortsUsage ports.PortUsage, novusState *novus.NovusState)
 	dnsPort := GetDNSPort(novusState)
 	novusState.DNSMasq.Port = dnsPort
 
-	if portUsedBy, isUsed := initialPortsUsage[dnsPort]; isUsed && portUsedBy != "dnsmasq" {
-		logger.Errorf("Cannot start DNSMasq: Port %s is already used by '%s'", dnsPort, portUsedBy)
+	if portUsedBy, isUsed := initialPortsUsage[dnsPort]; isUsed && portUsedBy != dnsProcessName() {
+		logger.Errorf("Cannot start %s: Port %s is already used by '%s'", ServerName(), dnsPort, portUsedBy)
 
 		// Ask user for an alternative port
 		var alternativePort string
@@ -148,7 +242,7 @@ func EnsurePort(initialPortsUsage ports.PortUsage, novusState *novus.NovusState)
 		for {
 			if attempts >= 3 {
 				logger.Errorf("Failed to set an alternative DNS port after %d attempts, exiting.", attempts)
-				os.Exit(1)
+				process.Exit(1)
 			}
 
 			alternativePort = tui.AskUser("Choose an alternative port for DNS: ")
@@ -176,6 +270,7 @@ func EnsurePort(initialPortsUsage ports.PortUsage, novusState *novus.NovusState)
 		for tld := range novusState.DnsFiles {
 			registerTLDResolver(tld, alternativePort)
 		}
+		restartSystemResolver()
 
 		novusState.DNSMasq.Port = alternativePort
 	}
@@ -0,0 +1,65 @@
+package dns_server
+
+import (
+	"syscall"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/daemon"
+	"github.com/jozefcipa/novus/internal/dry_run"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/transaction"
+)
+
+const daemonName = "dns"
+
+// Restart (re)starts the DNS server background process listening on the given port
+func Restart(port string) {
+	if dry_run.Enabled {
+		dry_run.Record(dry_run.Action{Type: dry_run.RestartService, Target: "novus-dns"})
+		return
+	}
+
+	// If anything fails later on, the server needs to be restarted again to use the restored port
+	transaction.OnRollback("novus-dns", func() { Restart(port) })
+
+	loader := logger.Loadingf("Restarting Novus DNS server")
+	stop()
+
+	if err := daemon.Start(daemonName, "dns", "run", "--port", port); err != nil {
+		loader.Errorf("Failed to start Novus DNS server.")
+		logger.Errorf(err.Error())
+		logger.Hintf("See %s for more info.", LogFilePath())
+		process.Exit(1)
+	}
+
+	loader.Checkf("Novus DNS server restarted")
+}
+
+func Stop() {
+	loader := logger.Loadingf("Stopping Novus DNS server")
+	stop()
+	loader.Infof("🚫 Novus DNS server stopped")
+}
+
+func IsRunning() bool {
+	_, running := daemon.IsRunning(daemonName)
+	return running
+}
+
+func LogFilePath() string {
+	return daemon.LogFilePath(daemonName)
+}
+
+// Stops the process and waits until it exits, so the port is released
+func stop() {
+	pid, running := daemon.IsRunning(daemonName)
+	if !running || !daemon.Stop(daemonName) {
+		return
+	}
+
+	// Signal 0 only checks whether the process still exists
+	for i := 0; i < 20 && syscall.Kill(pid, syscall.Signal(0)) == nil; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,164 @@
+package dns_server
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/logger"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Built-in alternative to DNSMasq, it resolves the Novus domains to localhost.
+// It's enabled by `dns: builtin` in ~/.novus/settings.yml and runs as a Novus background process.
+
+// Records are not cached, so changes in routing are visible immediately
+const recordTTL = 0
+
+const tcpTimeout = 10 * time.Second
+
+var localhostIPv4 = [4]byte{127, 0, 0, 1}
+var localhostIPv6 = [16]byte{15: 1}
+
+// Serve answers DNS queries on 127.0.0.1:port (UDP and TCP) until the process is terminated
+func Serve(port string) error {
+	address := net.JoinHostPort("127.0.0.1", port)
+	records := newZone()
+	records.refresh()
+
+	udpConn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return err
+	}
+	defer udpConn.Close()
+
+	tcpListener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	defer tcpListener.Close()
+
+	// Shut down on termination
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+
+		udpConn.Close()
+		tcpListener.Close()
+	}()
+
+	go serveTCP(tcpListener, records)
+
+	logger.Infof("Novus DNS server listening on %s", address)
+	return serveUDP(udpConn, records)
+}
+
+func serveUDP(conn net.PacketConn, records *zone) error {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		response, err := answer(buf[:n], records)
+		if err != nil {
+			logger.Debugf("Invalid DNS query from %s: %v", addr, err)
+			continue
+		}
+		conn.WriteTo(response, addr)
+	}
+}
+
+func serveTCP(listener net.Listener, records *zone) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer conn.Close()
+
+			// Every message is prefixed with its length (RFC 1035, 4.2.2)
+			for {
+				conn.SetDeadline(time.Now().Add(tcpTimeout))
+
+				var length uint16
+				if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+					return
+				}
+				query := make([]byte, length)
+				if _, err := io.ReadFull(conn, query); err != nil {
+					return
+				}
+
+				response, err := answer(query, records)
+				if err != nil {
+					logger.Debugf("Invalid DNS query from %s: %v", conn.RemoteAddr(), err)
+					return
+				}
+				binary.Write(conn, binary.BigEndian, uint16(len(response)))
+				conn.Write(response)
+			}
+		}()
+	}
+}
+
+// Builds the response for a single query
+func answer(query []byte, records *zone) ([]byte, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil {
+		return nil, err
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return nil, err
+	}
+
+	response := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:                 header.ID,
+			Response:           true,
+			OpCode:             header.OpCode,
+			Authoritative:      true,
+			RecursionDesired:   header.RecursionDesired,
+			RecursionAvailable: false,
+		},
+		Questions: []dnsmessage.Question{question},
+	}
+
+	records.refresh()
+	name := strings.TrimSuffix(question.Name.String(), ".")
+	if !records.matches(name) {
+		// Other domains are resolved by the system DNS, Novus only answers its own
+		logger.Debugf("DNS query refused [%s %s]", question.Type, name)
+		response.RCode = dnsmessage.RCodeRefused
+		return response.Pack()
+	}
+
+	resourceHeader := dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: recordTTL}
+	switch question.Type {
+	case dnsmessage.TypeA:
+		resourceHeader.Type = dnsmessage.TypeA
+		response.Answers = append(response.Answers, dnsmessage.Resource{Header: resourceHeader, Body: &dnsmessage.AResource{A: localhostIPv4}})
+	case dnsmessage.TypeAAAA:
+		resourceHeader.Type = dnsmessage.TypeAAAA
+		response.Answers = append(response.Answers, dnsmessage.Resource{Header: resourceHeader, Body: &dnsmessage.AAAAResource{AAAA: localhostIPv6}})
+	}
+	// Other record types exist for the name, just without any data (NOERROR)
+
+	logger.Debugf("DNS query answered [%s %s]", question.Type, name)
+	return response.Pack()
+}
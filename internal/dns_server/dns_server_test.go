@@ -0,0 +1,121 @@
+package dns_server
+
+import (
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func testZone() *zone {
+	records := newZone()
+	records.tlds = map[string]bool{"test": true}
+	records.domains = map[string]bool{"api.example.com": true}
+	return records
+}
+
+func TestZoneMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "api.test", want: true},
+		{name: "deep.api.test", want: true},
+		{name: "API.Test", want: true},
+		{name: "api.example.com", want: true},
+		{name: "web.example.com", want: false},
+		{name: "test.com", want: false},
+		{name: "google.com", want: false},
+	}
+
+	records := testZone()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if matches := records.matches(tt.name); matches != tt.want {
+				t.Errorf("matches(%s) = %t, want %t", tt.name, matches, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnswer(t *testing.T) {
+	tests := []struct {
+		name        string
+		domain      string
+		queryType   dnsmessage.Type
+		wantRCode   dnsmessage.RCode
+		wantAnswers []dnsmessage.ResourceBody
+	}{
+		{
+			name:        "A record",
+			domain:      "api.test.",
+			queryType:   dnsmessage.TypeA,
+			wantRCode:   dnsmessage.RCodeSuccess,
+			wantAnswers: []dnsmessage.ResourceBody{&dnsmessage.AResource{A: localhostIPv4}},
+		},
+		{
+			name:        "AAAA record",
+			domain:      "api.example.com.",
+			queryType:   dnsmessage.TypeAAAA,
+			wantRCode:   dnsmessage.RCodeSuccess,
+			wantAnswers: []dnsmessage.ResourceBody{&dnsmessage.AAAAResource{AAAA: localhostIPv6}},
+		},
+		{
+			name:      "other record type",
+			domain:    "api.test.",
+			queryType: dnsmessage.TypeMX,
+			wantRCode: dnsmessage.RCodeSuccess,
+		},
+		{
+			name:      "unknown domain",
+			domain:    "google.com.",
+			queryType: dnsmessage.TypeA,
+			wantRCode: dnsmessage.RCodeRefused,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := dnsmessage.Message{
+				Header: dnsmessage.Header{ID: 42, RecursionDesired: true},
+				Questions: []dnsmessage.Question{
+					{Name: dnsmessage.MustNewName(tt.domain), Type: tt.queryType, Class: dnsmessage.ClassINET},
+				},
+			}
+			packedQuery, err := query.Pack()
+			if err != nil {
+				t.Fatalf("Failed to pack query: %v", err)
+			}
+
+			packedResponse, err := answer(packedQuery, testZone())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var response dnsmessage.Message
+			if err := response.Unpack(packedResponse); err != nil {
+				t.Fatalf("Failed to unpack response: %v", err)
+			}
+
+			if response.ID != 42 || !response.Response {
+				t.Errorf("Unexpected response header %+v", response.Header)
+			}
+			if response.RCode != tt.wantRCode {
+				t.Errorf("RCode = %s, want %s", response.RCode, tt.wantRCode)
+			}
+			if len(response.Answers) != len(tt.wantAnswers) {
+				t.Fatalf("Got %d answers, want %d", len(response.Answers), len(tt.wantAnswers))
+			}
+			for i, answer := range response.Answers {
+				if answer.Body.GoString() != tt.wantAnswers[i].GoString() {
+					t.Errorf("Answer %d = %s, want %s", i, answer.Body.GoString(), tt.wantAnswers[i].GoString())
+				}
+			}
+		})
+	}
+}
+
+func TestAnswerInvalidQuery(t *testing.T) {
+	if _, err := answer([]byte{0, 1, 2}, testZone()); err == nil {
+		t.Errorf("Expected an error for an invalid query")
+	}
+}
@@ -0,0 +1,91 @@
+package dns_server
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/paths"
+)
+
+// Zone holds the names the server answers for, it's reloaded whenever the state file changes
+type zone struct {
+	mu sync.RWMutex
+	// TLDs registered in NovusState.DnsFiles, all their subdomains are resolved (same as dnsmasq `address=/test/127.0.0.1`)
+	tlds map[string]bool
+	// Exact domains of the active routes
+	domains map[string]bool
+	// Modification time of the loaded state file
+	loadedAt time.Time
+}
+
+func newZone() *zone {
+	return &zone{tlds: map[string]bool{}, domains: map[string]bool{}}
+}
+
+// Reloads the zone if the state file has been modified since the last load.
+// The state is read directly (not via novus.GetState()), so a broken state file never stops the server.
+func (z *zone) refresh() {
+	info, err := os.Stat(paths.NovusStateFilePath)
+	if err != nil {
+		return
+	}
+
+	z.mu.RLock()
+	upToDate := info.ModTime().Equal(z.loadedAt)
+	z.mu.RUnlock()
+	if upToDate {
+		return
+	}
+
+	content, err := os.ReadFile(paths.NovusStateFilePath)
+	if err != nil {
+		logger.Warnf("Failed to read state file: %v", err)
+		return
+	}
+
+	var state novus.NovusState
+	if err := json.Unmarshal(content, &state); err != nil {
+		logger.Warnf("Failed to parse state file, keeping the previous records: %v", err)
+		return
+	}
+
+	tlds := map[string]bool{}
+	for tld := range state.DnsFiles {
+		tlds[strings.ToLower(tld)] = true
+	}
+
+	domains := map[string]bool{}
+	for _, appState := range state.GetActiveApps() {
+		for _, route := range appState.Routes {
+			domains[strings.ToLower(route.Domain)] = true
+		}
+	}
+
+	z.mu.Lock()
+	z.tlds = tlds
+	z.domains = domains
+	z.loadedAt = info.ModTime()
+	z.mu.Unlock()
+
+	logger.Debugf("DNS records reloaded [%d TLDs, %d domains]", len(tlds), len(domains))
+}
+
+// Matches checks whether the name (without the trailing dot) belongs to Novus
+func (z *zone) matches(name string) bool {
+	name = strings.ToLower(name)
+
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+
+	if z.domains[name] {
+		return true
+	}
+
+	labels := strings.Split(name, ".")
+	return z.tlds[labels[len(labels)-1]]
+}
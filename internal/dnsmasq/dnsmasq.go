@@ -2,37 +2,43 @@ package dnsmasq
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 
+	"github.com/jozefcipa/novus/internal/dry_run"
 	"github.com/jozefcipa/novus/internal/fs"
-	"github.com/jozefcipa/novus/internal/homebrew"
 	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/service_manager"
+	"github.com/jozefcipa/novus/internal/sudo"
+	"github.com/jozefcipa/novus/internal/transaction"
 )
 
-var dnsmasqConfFile string
-
 // On some systems, port 53 might be already used by another DNS or some other service (e.g. PaloAltos GlobalProtect VPN),
 // therefore we default to a different port
 // However, if this port is also used, user will be prompted to provide an alternative port
 const DefaultPort = "5053"
 
-func init() {
-	dnsmasqConfFile = filepath.Join(homebrew.HomebrewPrefix, "/etc/dnsmasq.conf")
-}
-
 func Restart() {
+	if dry_run.Enabled {
+		dry_run.Record(dry_run.Action{Type: dry_run.RestartService, Target: "dnsmasq"})
+		return
+	}
+
+	// If anything fails later on, DNSMasq needs to be restarted again to load the restored configuration
+	transaction.OnRollback("dnsmasq", Restart)
+
 	dnsMasqLoader := logger.Loadingf("DNSMasq restarting")
-	homebrew.RestartService("dnsmasq")
+	service_manager.Restart("dnsmasq")
 
 	// Check if the restart was successful
 	isDNSMasqRunning := IsRunning()
 	if !isDNSMasqRunning {
 		dnsMasqLoader.Errorf("Failed to restart DNSMasq.")
-		logger.Hintf("Try running \"brew services info dnsmasq --json\" for more info.")
-		os.Exit(1)
+		logger.Hintf("Try running \"%s\" for more info.", service_manager.InfoCommand("dnsmasq"))
+		process.Exit(1)
 	}
 
 	dnsMasqLoader.Checkf("DNSMasq restarted")
@@ -40,52 +46,83 @@ func Restart() {
 
 func Stop() {
 	nginxLoader := logger.Loadingf("Stopping DNSMasq")
-	homebrew.StopService("dnsmasq")
+	service_manager.Stop("dnsmasq")
 	nginxLoader.Infof("🚫 DNSMasq stopped")
 }
 
 func IsRunning() bool {
-	return homebrew.IsServiceRunning("dnsmasq")
+	return service_manager.IsRunning("dnsmasq")
 }
 
 func Configure(dnsPort string) bool {
 	if dnsPort == "" {
 		logger.Errorf("Called dnsmasq.Configure() with empty port")
-		os.Exit(1)
+		process.Exit(1)
 	}
 
+	// Make sure DNSMasq loads the configs generated by Novus
+	fs.MakeDirOrExit(paths.DNSMasqConfigDir)
+	ensureIncludeFile()
+
 	// Open DNSMasq configuration file
-	logger.Debugf("DNSMasq: Reading configuration file [%s]", dnsmasqConfFile)
-	confFile := string(fs.ReadFileOrExit(dnsmasqConfFile))
+	logger.Debugf("DNSMasq: Reading configuration file [%s]", paths.DNSMasqConfigFilePath)
+	confFile := defaultConfig()
+	if fs.FileExists(paths.DNSMasqConfigFilePath) {
+		confFile = string(fs.ReadFileOrExit(paths.DNSMasqConfigFilePath))
+	}
 
 	// Enable reading DNSMasq configurations from /etc/dnsmasq.d/* directory
 	updatedConf := strings.Replace(
 		confFile,
-		fmt.Sprintf("#conf-dir=%s/etc/dnsmasq.d/,*.conf", homebrew.HomebrewPrefix),
-		fmt.Sprintf("conf-dir=%s/etc/dnsmasq.d/,*.conf", homebrew.HomebrewPrefix),
+		fmt.Sprintf("#conf-dir=%s/,*.conf", paths.DNSMasqConfigDir),
+		fmt.Sprintf("conf-dir=%s/,*.conf", paths.DNSMasqConfigDir),
 		1,
 	)
 
 	// Enable alternative listening port
 	// (matches both "#port=5353" and "port=1234" (any number))
 	re := regexp.MustCompile(`#?port=\d+`)
-	updatedConf = re.ReplaceAllString(confFile, fmt.Sprintf("port=%s", dnsPort))
+	updatedConf = re.ReplaceAllString(updatedConf, fmt.Sprintf("port=%s", dnsPort))
 
 	// If the config differs (there was an actual change), write the changes
 	if confFile != updatedConf {
-		logger.Debugf("DNSMasq: Updating configuration file [%s]", dnsmasqConfFile)
-		fs.WriteFileOrExit(dnsmasqConfFile, updatedConf)
+		logger.Debugf("DNSMasq: Updating configuration file [%s]", paths.DNSMasqConfigFilePath)
+		transaction.TrackFile(paths.DNSMasqConfigFilePath)
+		fs.WriteFileOrExit(paths.DNSMasqConfigFilePath, updatedConf)
 
 		return true
 	} else {
-		logger.Debugf("DNSMasq: Configuration file is up to date [%s]", dnsmasqConfFile)
+		logger.Debugf("DNSMasq: Configuration file is up to date [%s]", paths.DNSMasqConfigFilePath)
 
 		return false
 	}
 }
 
+// Homebrew ships a default dnsmasq.conf, on Linux Novus creates its own config in ~/.novus.
+// DNSMasq only answers the Novus TLDs there and never forwards queries upstream,
+// as the upstream is systemd-resolved, which would send them back and create a loop.
+func defaultConfig() string {
+	return fmt.Sprintf("# Generated by Novus\nport=%s\nlisten-address=127.0.0.1\nbind-interfaces\nno-resolv\nconf-dir=%s/,*.conf\n", DefaultPort, paths.DNSMasqConfigDir)
+}
+
+// On Linux the DNSMasq configs are stored in ~/.novus, so they have to be included in the system-wide DNSMasq configuration
+func ensureIncludeFile() {
+	if paths.DNSMasqIncludeFilePath == "" {
+		return
+	}
+
+	includeConfig := fmt.Sprintf("# Generated by Novus\nconf-file=%s\n", paths.DNSMasqConfigFilePath)
+	if current, _ := fs.ReadFile(paths.DNSMasqIncludeFilePath); current == includeConfig {
+		return
+	}
+
+	logger.Debugf("DNSMasq: Registering Novus config [%s]", paths.DNSMasqIncludeFilePath)
+	transaction.TrackPrivilegedFile(paths.DNSMasqIncludeFilePath)
+	sudo.WriteFileOrExit(paths.DNSMasqIncludeFilePath, includeConfig)
+}
+
 func CreateTLDConfig(tld string) (bool, string) {
-	configPath := fmt.Sprintf(filepath.Join(homebrew.HomebrewPrefix, "/etc/dnsmasq.d/%s.conf"), tld)
+	configPath := filepath.Join(paths.DNSMasqConfigDir, fmt.Sprintf("%s.conf", tld))
 
 	// First check if the file already exists
 	if confExists := fs.FileExists(configPath); confExists {
@@ -100,6 +137,7 @@ func CreateTLDConfig(tld string) (bool, string) {
 	configContent := fmt.Sprintf("address=/%s/127.0.0.1", tld)
 
 	// Create a configuration file
+	transaction.TrackFile(configPath)
 	fs.WriteFileOrExit(configPath, configContent)
 	logger.Debugf("DNSMasq [*.%s]: Domain config saved [%s]", tld, configPath)
 
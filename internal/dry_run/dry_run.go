@@ -0,0 +1,138 @@
+package dry_run
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/stringutils"
+)
+
+// This variable gets its value in cmd/*.go from the `--dry-run` CLI flag
+// When enabled, Novus only computes the changes and records them here instead of applying them
+var Enabled bool
+
+type ActionType string
+
+const (
+	CreateFile        ActionType = "create file"
+	UpdateFile        ActionType = "update file"
+	DeleteFile        ActionType = "delete file"
+	CreateDir         ActionType = "create directory"
+	DeleteDir         ActionType = "delete directory"
+	ChangeOwner       ActionType = "change owner"
+	IssueCertificate  ActionType = "issue certificate"
+	DeleteCertificate ActionType = "delete certificate"
+	UnregisterTLD     ActionType = "unregister TLD"
+	RestartService    ActionType = "restart service"
+	ReloadService     ActionType = "reload service"
+	AddRoute          ActionType = "add route"
+	RemoveRoute       ActionType = "remove route"
+	ModifyRoute       ActionType = "modify route"
+)
+
+type Action struct {
+	Type   ActionType
+	Target string
+	// Whether the action requires sudo privileges
+	Sudo bool
+	// Unified diff of the file content (only for file changes)
+	Diff string
+}
+
+var actions []Action
+
+func Record(action Action) {
+	logger.Debugf("[dry-run] Skipping action: %s %s", action.Type, action.Target)
+	actions = append(actions, action)
+}
+
+func RecordFileWrite(path string, content string, sudo bool) {
+	oldContent := ""
+	actionType := CreateFile
+	if file, err := os.ReadFile(path); err == nil {
+		oldContent = string(file)
+		actionType = UpdateFile
+	}
+
+	// Nothing would change, no need to report it
+	if actionType == UpdateFile && oldContent == content {
+		logger.Debugf("[dry-run] File is up to date [%s]", path)
+		return
+	}
+
+	oldName := path
+	if actionType == CreateFile {
+		oldName = "/dev/null"
+	}
+
+	Record(Action{
+		Type:   actionType,
+		Target: path,
+		Sudo:   sudo,
+		Diff:   stringutils.UnifiedDiff(oldName, path, oldContent, content),
+	})
+}
+
+func RecordFileDelete(path string, sudo bool) {
+	Record(Action{Type: DeleteFile, Target: path, Sudo: sudo})
+}
+
+func RecordDirCreate(path string, sudo bool) {
+	// Only report directories that don't exist yet
+	if _, err := os.Stat(path); err == nil {
+		return
+	}
+
+	Record(Action{Type: CreateDir, Target: path, Sudo: sudo})
+}
+
+func PrintPlan() {
+	fmt.Println()
+	if len(actions) == 0 {
+		logger.Checkf("Dry run: Everything is up to date, no changes would be made.")
+		return
+	}
+
+	logger.Infof("Dry run: Novus would perform the following %d actions (nothing has been changed):\n", len(actions))
+
+	for _, action := range actions {
+		fmt.Print(formatAction(action))
+	}
+}
+
+// Returns the plan line of the action followed by its colored diff, if there's any
+func formatAction(action Action) string {
+	sudoInfo := ""
+	if action.Sudo {
+		sudoInfo = logger.GRAY + " (sudo)" + logger.RESET
+	}
+	output := fmt.Sprintf("%s• %s%s%s %s\n", logger.CYAN, action.Type, logger.RESET, sudoInfo, action.Target)
+
+	if action.Diff != "" {
+		output += formatDiff(action.Diff)
+	}
+
+	return output
+}
+
+func formatDiff(diff string) string {
+	output := ""
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		color := logger.GRAY
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			color = logger.WHITE
+		case strings.HasPrefix(line, "@@"):
+			color = logger.CYAN
+		case strings.HasPrefix(line, "+"):
+			color = logger.GREEN
+		case strings.HasPrefix(line, "-"):
+			color = logger.RED
+		}
+		output += fmt.Sprintf("    %s%s%s\n", color, line, logger.RESET)
+	}
+
+	return output
+}
@@ -0,0 +1,117 @@
+package dry_run
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jozefcipa/novus/internal/logger"
+)
+
+func TestRecordFileWrite(t *testing.T) {
+	dir := t.TempDir()
+	existingFile := filepath.Join(dir, "existing.conf")
+	if err := os.WriteFile(existingFile, []byte("listen 80;\n"), 0644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		content  string
+		sudo     bool
+		wantType ActionType
+		wantDiff string
+	}{
+		{
+			name:     "new file",
+			path:     filepath.Join(dir, "new.conf"),
+			content:  "listen 443;\n",
+			sudo:     true,
+			wantType: CreateFile,
+			wantDiff: "--- /dev/null\n+++ " + filepath.Join(dir, "new.conf") + "\n@@ -0,0 +1 @@\n+listen 443;\n",
+		},
+		{
+			name:     "changed file",
+			path:     existingFile,
+			content:  "listen 443;\n",
+			wantType: UpdateFile,
+			wantDiff: "--- " + existingFile + "\n+++ " + existingFile + "\n@@ -1 +1 @@\n-listen 80;\n+listen 443;\n",
+		},
+		{
+			name:    "unchanged file",
+			path:    existingFile,
+			content: "listen 80;\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actions = nil
+			RecordFileWrite(tt.path, tt.content, tt.sudo)
+
+			if tt.wantType == "" {
+				if len(actions) != 0 {
+					t.Errorf("Expected no actions, got %v", actions)
+				}
+				return
+			}
+
+			want := []Action{{Type: tt.wantType, Target: tt.path, Sudo: tt.sudo, Diff: tt.wantDiff}}
+			if !reflect.DeepEqual(actions, want) {
+				t.Errorf("Recorded actions = %#v, want %#v", actions, want)
+			}
+		})
+	}
+}
+
+func TestRecordDirCreate(t *testing.T) {
+	dir := t.TempDir()
+
+	actions = nil
+	RecordDirCreate(dir, false)
+	RecordDirCreate(filepath.Join(dir, "new"), true)
+
+	want := []Action{{Type: CreateDir, Target: filepath.Join(dir, "new"), Sudo: true}}
+	if !reflect.DeepEqual(actions, want) {
+		t.Errorf("Recorded actions = %#v, want %#v", actions, want)
+	}
+}
+
+func TestFormatAction(t *testing.T) {
+	tests := []struct {
+		name   string
+		action Action
+		want   string
+	}{
+		{
+			name:   "action without sudo",
+			action: Action{Type: DeleteCertificate, Target: "api.test"},
+			want:   logger.CYAN + "• delete certificate" + logger.RESET + " api.test\n",
+		},
+		{
+			name:   "action with sudo",
+			action: Action{Type: DeleteFile, Target: "/etc/resolver/test", Sudo: true},
+			want:   logger.CYAN + "• delete file" + logger.RESET + logger.GRAY + " (sudo)" + logger.RESET + " /etc/resolver/test\n",
+		},
+		{
+			name:   "action with diff",
+			action: Action{Type: UpdateFile, Target: "app.conf", Diff: "--- app.conf\n+++ app.conf\n@@ -1 +1 @@\n-listen 80;\n+listen 443;\n"},
+			want: logger.CYAN + "• update file" + logger.RESET + " app.conf\n" +
+				"    " + logger.WHITE + "--- app.conf" + logger.RESET + "\n" +
+				"    " + logger.WHITE + "+++ app.conf" + logger.RESET + "\n" +
+				"    " + logger.CYAN + "@@ -1 +1 @@" + logger.RESET + "\n" +
+				"    " + logger.RED + "-listen 80;" + logger.RESET + "\n" +
+				"    " + logger.GREEN + "+listen 443;" + logger.RESET + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if output := formatAction(tt.action); output != tt.want {
+				t.Errorf("formatAction() = %q, want %q", output, tt.want)
+			}
+		})
+	}
+}
@@ -2,15 +2,18 @@ package fs
 
 import (
 	"os"
+	"path/filepath"
 
+	"github.com/jozefcipa/novus/internal/dry_run"
 	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/process"
 )
 
 func ReadFileOrExit(path string) string {
 	file, err := os.ReadFile(path)
 	if err != nil {
 		logger.Errorf("Failed to read a file %s\n   Reason: %v", path, err)
-		os.Exit(1)
+		process.Exit(1)
 	}
 
 	return string(file)
@@ -26,14 +29,64 @@ func ReadFile(path string) (string, error) {
 }
 
 func WriteFileOrExit(path string, data string) {
+	if dry_run.Enabled {
+		dry_run.RecordFileWrite(path, data, false)
+		return
+	}
+
 	err := os.WriteFile(path, []byte(data), 0644)
 	if err != nil {
 		logger.Errorf("Failed to write to a file %s\n   Reason: %v", path, err)
-		os.Exit(1)
+		process.Exit(1)
+	}
+}
+
+// WriteFileAtomicOrExit writes the data to a temporary file first and then replaces the original file,
+// so the file is never left half-written (e.g. if Novus crashes or the disk is full)
+func WriteFileAtomicOrExit(path string, data string) {
+	if dry_run.Enabled {
+		dry_run.RecordFileWrite(path, data, false)
+		return
+	}
+
+	if err := writeFileAtomic(path, []byte(data)); err != nil {
+		logger.Errorf("Failed to write to a file %s\n   Reason: %v", path, err)
+		process.Exit(1)
 	}
 }
 
+func writeFileAtomic(path string, data []byte) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	// Clean up the temporary file if anything fails (after a successful rename it doesn't exist anymore)
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpFile.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), path)
+}
+
 func DeleteFile(path string) error {
+	if dry_run.Enabled {
+		dry_run.RecordFileDelete(path, false)
+		return nil
+	}
+
 	if err := os.Remove(path); err != nil {
 		logger.Errorf("Failed to delete file %s\n   Reason: %v", path, err)
 		return err
@@ -42,13 +95,23 @@ func DeleteFile(path string) error {
 }
 
 func MakeDirOrExit(path string) {
+	if dry_run.Enabled {
+		dry_run.RecordDirCreate(path, false)
+		return
+	}
+
 	if err := os.MkdirAll(path, os.ModePerm); err != nil {
 		logger.Errorf("Failed to create directory %s\n   Reason: %v", path, err)
-		os.Exit(1)
+		process.Exit(1)
 	}
 }
 
 func DeleteDir(path string) error {
+	if dry_run.Enabled {
+		dry_run.Record(dry_run.Action{Type: dry_run.DeleteDir, Target: path})
+		return nil
+	}
+
 	if err := os.RemoveAll(path); err != nil {
 		logger.Errorf("Failed to delete directory %s\n   Reason: %v", path, err)
 		return err
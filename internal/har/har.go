@@ -0,0 +1,252 @@
+package har
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+	"unicode/utf8"
+)
+
+// Types follow the HAR 1.2 specification (http://www.softwareishard.com/blog/har-12-spec/)
+// Only the fields Novus is able to fill in are included.
+
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+}
+
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type Entry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	Time            float64   `json:"time"` // in milliseconds
+	Request         Request   `json:"request"`
+	Response        Response  `json:"response"`
+	Cache           struct{}  `json:"cache"`
+	Timings         Timings   `json:"timings"`
+	ServerIPAddress string    `json:"serverIPAddress,omitempty"`
+	Comment         string    `json:"comment,omitempty"`
+}
+
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int64       `json:"bodySize"`
+}
+
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int64       `json:"bodySize"`
+}
+
+type Cookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"` // Not in the spec for postData, but used by some tools for binary bodies
+	Comment  string `json:"comment,omitempty"`
+}
+
+type Content struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type Timings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// Body is a (possibly truncated) copy of a request or response body
+type Body struct {
+	Data      []byte
+	Size      int64 // Size of the whole body, might be bigger than len(Data) if truncated
+	Truncated bool
+}
+
+func New(creatorVersion string, entries []Entry) HAR {
+	return HAR{
+		Log: Log{
+			Version: "1.2",
+			Creator: Creator{Name: "novus", Version: creatorVersion},
+			Entries: entries,
+		},
+	}
+}
+
+func (h HAR) WriteFile(path string) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+func ReadFile(path string) (HAR, error) {
+	var h HAR
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return h, err
+	}
+	if err := json.Unmarshal(data, &h); err != nil {
+		return h, fmt.Errorf("invalid HAR file: %v", err)
+	}
+
+	return h, nil
+}
+
+func NewRequest(req *http.Request, requestURL *url.URL, body Body) Request {
+	harReq := Request{
+		Method:      req.Method,
+		URL:         requestURL.String(),
+		HTTPVersion: req.Proto,
+		Cookies:     requestCookies(req),
+		Headers:     headers(req.Header),
+		QueryString: queryString(requestURL),
+		HeadersSize: -1,
+		BodySize:    body.Size,
+	}
+
+	if body.Size > 0 {
+		text, encoding := encodeBody(body.Data)
+		harReq.PostData = &PostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     text,
+			Encoding: encoding,
+			Comment:  truncatedComment(body),
+		}
+	}
+
+	return harReq
+}
+
+func NewResponse(resp *http.Response, body Body) Response {
+	text, encoding := encodeBody(body.Data)
+
+	return Response{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     responseCookies(resp),
+		Headers:     headers(resp.Header),
+		Content: Content{
+			Size:     body.Size,
+			MimeType: resp.Header.Get("Content-Type"),
+			Text:     text,
+			Encoding: encoding,
+			Comment:  truncatedComment(body),
+		},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    body.Size,
+	}
+}
+
+// DecodeText returns the original body bytes of the HAR text field
+func DecodeText(text string, encoding string) ([]byte, error) {
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(text)
+	}
+
+	return []byte(text), nil
+}
+
+// Binary bodies cannot be stored as plain JSON strings
+func encodeBody(data []byte) (string, string) {
+	if utf8.Valid(data) {
+		return string(data), ""
+	}
+
+	return base64.StdEncoding.EncodeToString(data), "base64"
+}
+
+func truncatedComment(body Body) string {
+	if body.Truncated {
+		return fmt.Sprintf("Body truncated, only the first %d of %d bytes were captured", len(body.Data), body.Size)
+	}
+
+	return ""
+}
+
+func headers(header http.Header) []NameValue {
+	values := []NameValue{}
+	for _, name := range slices.Sorted(maps.Keys(header)) {
+		for _, value := range header[name] {
+			values = append(values, NameValue{Name: name, Value: value})
+		}
+	}
+
+	return values
+}
+
+func queryString(requestURL *url.URL) []NameValue {
+	values := []NameValue{}
+	query := requestURL.Query()
+	for _, name := range slices.Sorted(maps.Keys(query)) {
+		for _, value := range query[name] {
+			values = append(values, NameValue{Name: name, Value: value})
+		}
+	}
+
+	return values
+}
+
+func requestCookies(req *http.Request) []Cookie {
+	cookies := []Cookie{}
+	for _, cookie := range req.Cookies() {
+		cookies = append(cookies, Cookie{Name: cookie.Name, Value: cookie.Value})
+	}
+
+	return cookies
+}
+
+func responseCookies(resp *http.Response) []Cookie {
+	cookies := []Cookie{}
+	for _, cookie := range resp.Cookies() {
+		cookies = append(cookies, Cookie{Name: cookie.Name, Value: cookie.Value})
+	}
+
+	return cookies
+}
@@ -0,0 +1,92 @@
+package health
+
+import (
+	"cmp"
+	"net"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/maputils"
+	"github.com/jozefcipa/novus/internal/novus"
+)
+
+const upstreamDialTimeout = time.Second
+
+type UpstreamHealth struct {
+	App       string `json:"app"`
+	Domain    string `json:"domain"`
+	Upstream  string `json:"upstream"`
+	Healthy   bool   `json:"healthy"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// CheckActiveRoutes checks whether the upstreams of all active routes accept connections
+func CheckActiveRoutes(novusState novus.NovusState) []*UpstreamHealth {
+	appNames := maputils.MapKeys(novusState.Apps)
+	slices.SortFunc(appNames, func(a, b string) int { return cmp.Compare(a, b) })
+
+	results := []*UpstreamHealth{}
+	for _, appName := range appNames {
+		appState := novusState.Apps[appName]
+		if appName == novus.NovusInternalAppName || appState.Status != novus.APP_ACTIVE {
+			continue
+		}
+
+		for _, route := range appState.Routes {
+			results = append(results, &UpstreamHealth{App: appName, Domain: route.Domain, Upstream: route.Upstream})
+		}
+	}
+
+	// Check all upstreams in parallel
+	var wg sync.WaitGroup
+	for _, result := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkUpstream(result)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+func checkUpstream(result *UpstreamHealth) {
+	address, err := UpstreamAddress(result.Upstream)
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", address, upstreamDialTimeout)
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+	conn.Close()
+
+	result.Healthy = true
+	result.LatencyMs = time.Since(start).Milliseconds()
+}
+
+// Returns host:port of the upstream URL (e.g. http://localhost:3000 => localhost:3000)
+func UpstreamAddress(upstream string) (string, error) {
+	upstreamURL, err := url.Parse(upstream)
+	if err != nil {
+		return "", err
+	}
+
+	port := upstreamURL.Port()
+	if port == "" {
+		port = "80"
+		if upstreamURL.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	return net.JoinHostPort(upstreamURL.Hostname(), port), nil
+}
@@ -0,0 +1,157 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/jozefcipa/novus/internal/homebrew"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/settings"
+)
+
+// Installer installs the binaries Novus depends on via a package manager
+type Installer interface {
+	Name() string
+	// Whether the package manager can be used on this system
+	IsAvailable() bool
+	// Command that installs the package, nil if the installer can't install packages
+	InstallCommand(pkg string) []string
+}
+
+// All supported installers, in the order they are detected on Linux
+var installers = []Installer{&apt{}, &dnf{}, &pacman{}, &brew{}, &path{}}
+
+func Names() []string {
+	names := []string{}
+	for _, installer := range installers {
+		names = append(names, installer.Name())
+	}
+	return names
+}
+
+// Get returns the installer by its name
+func Get(name string) (Installer, error) {
+	for _, installer := range installers {
+		if installer.Name() == name {
+			return installer, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Unknown installer \"%s\", available installers: %s", name, strings.Join(Names(), ", "))
+}
+
+// Detect picks the installer for the current platform,
+// Homebrew on macOS and the first available system package manager on Linux
+func Detect() Installer {
+	if runtime.GOOS == "darwin" {
+		return &brew{}
+	}
+
+	for _, installer := range installers {
+		if installer.IsAvailable() {
+			logger.Debugf("Detected installer [%s]", installer.Name())
+			return installer
+		}
+	}
+
+	return &path{}
+}
+
+// CheckRequiredBinaries checks that all binaries are installed in the supported versions
+func CheckRequiredBinaries() error {
+	for _, binary := range requiredBinaries() {
+		if !binExists(binary.Name) {
+			return fmt.Errorf("%s is not installed on this system!", binary.Label)
+		}
+
+		if err := binary.CheckVersion(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// InstallBinaries installs the missing binaries and checks the versions of the installed ones
+func InstallBinaries(installer Installer) error {
+	logger.Debugf("Installing binaries via %s", installer.Name())
+
+	for _, binary := range requiredBinaries() {
+		if !binExists(binary.Name) {
+			if err := install(installer, binary); err != nil {
+				return err
+			}
+		}
+
+		if err := binary.CheckVersion(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func install(installer Installer, binary Binary) error {
+	if !installer.IsAvailable() {
+		// Keep the dedicated error, so the user can be pointed to the Homebrew website
+		if _, ok := installer.(*brew); ok {
+			return &homebrew.HomebrewMissingError{}
+		}
+		return fmt.Errorf("%s is not installed, therefore %s cannot be installed", installer.Name(), binary.Label)
+	}
+
+	command := installer.InstallCommand(binary.Name)
+	if command == nil {
+		return fmt.Errorf("%s is not installed on this system! Install it manually and make sure it's available in $PATH.", binary.Label)
+	}
+
+	// Always show what is going to be run, as it might ask for sudo password
+	commandString := strings.Join(command, " ")
+	logger.Infof("⏳ Installing %s...", binary.Label)
+	logger.Infof("   $ %s", commandString)
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("An error occurred while installing \"%s\" (%s).\n\n%+v", binary.Label, commandString, err)
+	}
+	fmt.Println() // print empty line
+
+	// Check whether the binary is discoverable (in $PATH)
+	if !binExists(binary.Name) {
+		logger.Errorf("%s has been installed but cannot be executed.", binary.Label)
+		logger.Hintf("The binary is probably not registered in the $PATH variable.")
+		if _, ok := installer.(*brew); ok {
+			logger.Infof("   Run \"brew doctor\" or view https://github.com/jozefcipa/novus/issues/3 for more information.")
+		}
+		process.Exit(1)
+	}
+
+	logger.Successf("%s installed", binary.Label)
+
+	return nil
+}
+
+// DNSMasq and Nginx are not needed if the built-in DNS server and proxy are used
+func requiredBinaries() []Binary {
+	return slices.DeleteFunc(slices.Clone(RequiredBinaries), func(binary Binary) bool {
+		return (binary.Name == "dnsmasq" && settings.UseBuiltinDNS()) ||
+			(binary.Name == "nginx" && settings.UseBuiltinProxy())
+	})
+}
+
+func binExists(bin string) bool {
+	_, err := exec.LookPath(bin)
+	exists := err == nil
+
+	logger.Debugf("Checking if binary exists [%s=%t]", bin, exists)
+
+	return exists
+}
@@ -0,0 +1,80 @@
+package installer
+
+import (
+	"github.com/jozefcipa/novus/internal/homebrew"
+)
+
+// Installs packages via Homebrew, used on macOS
+type brew struct{}
+
+func (b *brew) Name() string {
+	return "brew"
+}
+
+func (b *brew) IsAvailable() bool {
+	return homebrew.IsInstalled()
+}
+
+func (b *brew) InstallCommand(pkg string) []string {
+	return []string{"brew", "install", pkg}
+}
+
+// Debian, Ubuntu and derivatives
+type apt struct{}
+
+func (a *apt) Name() string {
+	return "apt"
+}
+
+func (a *apt) IsAvailable() bool {
+	return binExists("apt-get")
+}
+
+func (a *apt) InstallCommand(pkg string) []string {
+	return []string{"sudo", "apt-get", "install", "-y", pkg}
+}
+
+// Fedora, RHEL and derivatives
+type dnf struct{}
+
+func (d *dnf) Name() string {
+	return "dnf"
+}
+
+func (d *dnf) IsAvailable() bool {
+	return binExists("dnf")
+}
+
+func (d *dnf) InstallCommand(pkg string) []string {
+	return []string{"sudo", "dnf", "install", "-y", pkg}
+}
+
+// Arch Linux and derivatives
+type pacman struct{}
+
+func (p *pacman) Name() string {
+	return "pacman"
+}
+
+func (p *pacman) IsAvailable() bool {
+	return binExists("pacman")
+}
+
+func (p *pacman) InstallCommand(pkg string) []string {
+	return []string{"sudo", "pacman", "-S", "--needed", "--noconfirm", pkg}
+}
+
+// Doesn't install anything, the binaries have to be installed manually and available in $PATH
+type path struct{}
+
+func (p *path) Name() string {
+	return "path"
+}
+
+func (p *path) IsAvailable() bool {
+	return true
+}
+
+func (p *path) InstallCommand(pkg string) []string {
+	return nil
+}
@@ -0,0 +1,108 @@
+package installer
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jozefcipa/novus/internal/logger"
+)
+
+// Binary describes a program Novus depends on
+type Binary struct {
+	Name string
+	// Name shown to the user
+	Label string
+	// Oldest version Novus has been tested with
+	MinVersion string
+	// Arguments that print the version (some programs print it to stderr)
+	versionArgs []string
+	// Extracts the version number from the output
+	versionPattern *regexp.Regexp
+}
+
+var RequiredBinaries = []Binary{
+	{
+		Name:       "nginx",
+		Label:      "Nginx",
+		MinVersion: "1.18.0",
+		// nginx version: nginx/1.25.3
+		versionArgs:    []string{"-v"},
+		versionPattern: regexp.MustCompile(`nginx/(\d+(?:\.\d+)*)`),
+	},
+	{
+		Name:       "dnsmasq",
+		Label:      "DNSMasq",
+		MinVersion: "2.80",
+		// Dnsmasq version 2.90  Copyright (c) 2000-2024 Simon Kelley
+		versionArgs:    []string{"--version"},
+		versionPattern: regexp.MustCompile(`[Vv]ersion (\d+(?:\.\d+)*)`),
+	},
+}
+
+type BinaryVersionError struct {
+	Binary  Binary
+	Version string
+}
+
+func (e *BinaryVersionError) Error() string {
+	return fmt.Sprintf("%s %s is installed, but Novus requires at least version %s", e.Binary.Label, e.Version, e.Binary.MinVersion)
+}
+
+// Version runs the binary and returns its version
+func (b Binary) Version() (string, error) {
+	logger.Debugf("Running \"%s %s\"", b.Name, strings.Join(b.versionArgs, " "))
+	out, err := exec.Command(b.Name, b.versionArgs...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("Failed to get %s version: %v", b.Label, err)
+	}
+
+	match := b.versionPattern.FindStringSubmatch(string(out))
+	if match == nil {
+		return "", fmt.Errorf("Failed to parse %s version from \"%s\"", b.Label, strings.TrimSpace(string(out)))
+	}
+
+	logger.Debugf("Binary version [%s=%s]", b.Name, match[1])
+	return match[1], nil
+}
+
+// CheckVersion returns BinaryVersionError if the installed version is older than the minimal one
+func (b Binary) CheckVersion() error {
+	version, err := b.Version()
+	if err != nil {
+		return err
+	}
+
+	if compareVersions(version, b.MinVersion) < 0 {
+		return &BinaryVersionError{Binary: b, Version: version}
+	}
+
+	return nil
+}
+
+// Compares dot-separated version numbers, missing parts are treated as 0 (1.4 == 1.4.0)
+func compareVersions(a string, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+
+	for i := 0; i < max(len(aParts), len(bParts)); i++ {
+		var aNum, bNum int
+		if i < len(aParts) {
+			aNum, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			bNum, _ = strconv.Atoi(bParts[i])
+		}
+
+		if aNum != bNum {
+			if aNum < bNum {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
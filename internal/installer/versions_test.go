@@ -0,0 +1,62 @@
+package installer
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{a: "1.25.3", b: "1.18.0", want: 1},
+		{a: "1.18.0", b: "1.25.3", want: -1},
+		{a: "1.18.0", b: "1.18.0", want: 0},
+		{a: "1.4", b: "1.4.0", want: 0},
+		{a: "1.4.1", b: "1.4", want: 1},
+		{a: "2.9", b: "2.80", want: -1},
+		{a: "2.90", b: "2.80", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			if result := compareVersions(tt.a, tt.b); result != tt.want {
+				t.Errorf("compareVersions(%s, %s) = %d, want %d", tt.a, tt.b, result, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionPatterns(t *testing.T) {
+	tests := []struct {
+		binary string
+		output string
+		want   string
+	}{
+		{binary: "nginx", output: "nginx version: nginx/1.25.3\n", want: "1.25.3"},
+		{binary: "nginx", output: "nginx version: nginx/1.18.0 (Ubuntu)\n", want: "1.18.0"},
+		{binary: "dnsmasq", output: "Dnsmasq version 2.90  Copyright (c) 2000-2024 Simon Kelley\nCompile time options: IPv6 GNU-getopt", want: "2.90"},
+		{binary: "dnsmasq", output: "dnsmasq: unknown option", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.binary+": "+tt.output, func(t *testing.T) {
+			var binary Binary
+			for _, requiredBinary := range RequiredBinaries {
+				if requiredBinary.Name == tt.binary {
+					binary = requiredBinary
+				}
+			}
+			if binary.versionPattern == nil {
+				t.Fatalf("Binary %s is not required", tt.binary)
+			}
+
+			version := ""
+			if match := binary.versionPattern.FindStringSubmatch(tt.output); match != nil {
+				version = match[1]
+			}
+			if version != tt.want {
+				t.Errorf("Parsed version = %q, want %q", version, tt.want)
+			}
+		})
+	}
+}
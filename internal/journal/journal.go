@@ -0,0 +1,206 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/dry_run"
+	"github.com/jozefcipa/novus/internal/fs"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/sharedtypes"
+)
+
+// How many entries are kept in the journal
+const MaxEntries = 200
+
+// Entry describes a single command that changed the routing
+type Entry struct {
+	ID      int       `json:"id"`
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Args    []string  `json:"args,omitempty"`
+	App     string    `json:"app,omitempty"`
+	// App before and after the command, nil if the app didn't exist
+	Before *AppSnapshot `json:"before,omitempty"`
+	After  *AppSnapshot `json:"after,omitempty"`
+	// Set on entries created by `novus undo`, contains the ID of the reverted entry
+	UndoOf int `json:"undoOf,omitempty"`
+	// Whether the entry has been reverted by `novus undo`
+	Undone bool `json:"undone,omitempty"`
+}
+
+type AppSnapshot struct {
+	Directory string              `json:"directory"`
+	Status    novus.AppStatus     `json:"status"`
+	Routes    []sharedtypes.Route `json:"routes"`
+}
+
+// Snapshot copies the parts of the app state needed to restore it later, returns nil if the app doesn't exist
+func Snapshot(appState *novus.AppState) *AppSnapshot {
+	if appState == nil {
+		return nil
+	}
+
+	return &AppSnapshot{
+		Directory: appState.Directory,
+		Status:    appState.Status,
+		Routes:    slices.Clone(appState.Routes),
+	}
+}
+
+// SnapshotApp is the same as Snapshot, but looks up the app in the state first
+func SnapshotApp(appName string) *AppSnapshot {
+	appState, exists := novus.GetAppState(appName)
+	if !exists {
+		return nil
+	}
+
+	return Snapshot(appState)
+}
+
+// Record appends a new entry to the journal
+func Record(entry Entry) {
+	if dry_run.Enabled {
+		return
+	}
+
+	entries := List()
+	entry.ID = 1
+	if len(entries) > 0 {
+		entry.ID = entries[len(entries)-1].ID + 1
+	}
+	entry.Time = time.Now()
+
+	// Only the most recent entries are kept
+	entries = append(entries, entry)
+	if len(entries) > MaxEntries {
+		entries = entries[len(entries)-MaxEntries:]
+	}
+
+	logger.Debugf("Recording journal entry #%d [%s]", entry.ID, entry.Command)
+	write(entries)
+}
+
+// List returns all entries, the oldest first
+func List() []Entry {
+	entries := []Entry{}
+
+	file, err := os.Open(paths.NovusHistoryFilePath)
+	if err != nil {
+		return entries
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var entry Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			logger.Debugf("Skipping invalid journal entry: %v", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// LastUndoable returns the most recent entry that hasn't been reverted yet (undo entries themselves are skipped)
+func LastUndoable() (Entry, bool) {
+	entries := List()
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !entries[i].Undone && entries[i].UndoOf == 0 {
+			return entries[i], true
+		}
+	}
+
+	return Entry{}, false
+}
+
+// MarkUndone marks the entry as reverted, so the next `novus undo` continues with the previous one
+func MarkUndone(id int) {
+	if dry_run.Enabled {
+		return
+	}
+
+	entries := List()
+	for i := range entries {
+		if entries[i].ID == id {
+			entries[i].Undone = true
+		}
+	}
+	write(entries)
+}
+
+func write(entries []Entry) {
+	lines := []string{}
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		lines = append(lines, string(line))
+	}
+
+	fs.WriteFileAtomicOrExit(paths.NovusHistoryFilePath, strings.Join(lines, "\n")+"\n")
+}
+
+// RecordAppChange records the command only if it actually changed the app
+func RecordAppChange(command string, args []string, appName string, before *AppSnapshot, after *AppSnapshot) {
+	if reflect.DeepEqual(before, after) {
+		logger.Debugf("App %s has not changed, skipping journal entry", appName)
+		return
+	}
+
+	Record(Entry{Command: command, Args: args, App: appName, Before: before, After: after})
+}
+
+// Changes describes how the routes of the app changed, e.g. "+api.test, -web.test, ~admin.test"
+func (entry Entry) Changes() string {
+	if entry.Command == "trust" {
+		return ""
+	}
+
+	if entry.Before != nil && entry.After != nil && entry.Before.Status != entry.After.Status {
+		return fmt.Sprintf("%s → %s", entry.Before.Status, entry.After.Status)
+	}
+
+	routesBefore := map[string]sharedtypes.Route{}
+	if entry.Before != nil {
+		for _, route := range entry.Before.Routes {
+			routesBefore[route.Domain] = route
+		}
+	}
+
+	changes := []string{}
+	if entry.After != nil {
+		for _, route := range entry.After.Routes {
+			previous, existed := routesBefore[route.Domain]
+			if !existed {
+				changes = append(changes, "+"+route.Domain)
+			} else if previous != route {
+				changes = append(changes, "~"+route.Domain)
+			}
+			delete(routesBefore, route.Domain)
+		}
+	}
+	for _, route := range routesBefore {
+		changes = append(changes, "-"+route.Domain)
+	}
+	slices.SortFunc(changes, func(a, b string) int { return strings.Compare(a[1:], b[1:]) })
+
+	return strings.Join(changes, ", ")
+}
@@ -0,0 +1,211 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/request_log"
+)
+
+// Upper bounds of the latency histogram buckets (in seconds)
+var LatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Collector aggregates the requests from the access logs of all apps.
+// The logs are read incrementally, so the counters only grow while the collector is running
+// (even if the log files are rotated in the meantime).
+type Collector struct {
+	mu      sync.Mutex
+	series  map[seriesKey]*Series
+	readers map[string]*logReader // app name -> access log reader
+}
+
+type seriesKey struct {
+	app    string
+	domain string
+}
+
+// Series holds all the metrics of a single domain
+type Series struct {
+	App    string
+	Domain string
+
+	Requests       int64
+	StatusClasses  map[string]int64 // e.g. "2xx" -> count
+	ResponseBytes  int64
+	UpstreamErrors int64
+
+	// Cumulative counts of requests per latency bucket, the last item is +Inf
+	LatencyBuckets []int64
+	LatencySum     float64
+}
+
+// Keeps track of how far the access log has been read
+type logReader struct {
+	path    string
+	offset  int64
+	info    os.FileInfo
+	partial string
+}
+
+func NewCollector() *Collector {
+	return &Collector{
+		series:  map[seriesKey]*Series{},
+		readers: map[string]*logReader{},
+	}
+}
+
+// Update reads the requests logged since the last update
+func (c *Collector) Update() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	appDirs, err := os.ReadDir(paths.NovusLogsDir)
+	if err != nil {
+		logger.Debugf("Failed to read logs directory [%s]: %v", paths.NovusLogsDir, err)
+		return
+	}
+
+	for _, appDir := range appDirs {
+		if !appDir.IsDir() {
+			continue
+		}
+		appName := appDir.Name()
+
+		reader, exists := c.readers[appName]
+		if !exists {
+			reader = &logReader{path: paths.AppAccessLogFilePath(appName)}
+			c.readers[appName] = reader
+
+			// Include the most recently rotated file when the collector starts, so the metrics don't start from zero
+			for _, line := range request_log.ReadLines(paths.AppAccessLogFilePath(appName) + ".1") {
+				c.addLine(appName, line)
+			}
+		}
+
+		for _, line := range reader.readNewLines() {
+			c.addLine(appName, line)
+		}
+	}
+}
+
+// Snapshot returns a copy of all series
+func (c *Collector) Snapshot() []Series {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snapshot := []Series{}
+	for _, series := range c.series {
+		copied := *series
+		copied.StatusClasses = map[string]int64{}
+		for class, count := range series.StatusClasses {
+			copied.StatusClasses[class] = count
+		}
+		copied.LatencyBuckets = append([]int64{}, series.LatencyBuckets...)
+		snapshot = append(snapshot, copied)
+	}
+
+	return snapshot
+}
+
+func (c *Collector) addLine(appName string, line string) {
+	entry, ok := request_log.ParseLine(line)
+	if !ok {
+		return
+	}
+
+	key := seriesKey{app: appName, domain: entry.Host}
+	series, exists := c.series[key]
+	if !exists {
+		series = &Series{
+			App:            appName,
+			Domain:         entry.Host,
+			StatusClasses:  map[string]int64{},
+			LatencyBuckets: make([]int64, len(LatencyBuckets)+1),
+		}
+		c.series[key] = series
+	}
+
+	series.Add(entry)
+}
+
+func (s *Series) Add(entry request_log.Entry) {
+	s.Requests++
+	s.StatusClasses[StatusClass(entry.Status)]++
+	s.ResponseBytes += entry.Bytes
+	if IsUpstreamError(entry) {
+		s.UpstreamErrors++
+	}
+
+	s.LatencySum += entry.Duration
+	for i, bound := range LatencyBuckets {
+		if entry.Duration <= bound {
+			s.LatencyBuckets[i]++
+		}
+	}
+	s.LatencyBuckets[len(LatencyBuckets)]++ // +Inf
+}
+
+// StatusClass returns e.g. "2xx" for 200
+func StatusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return fmt.Sprintf("%dxx", status/100)
+}
+
+// IsUpstreamError returns true if Nginx couldn't get a response from the upstream (e.g. the service is not running)
+func IsUpstreamError(entry request_log.Entry) bool {
+	return entry.Status == 502 || entry.Status == 504 || strings.Contains(entry.UpstreamStatus, "502") || strings.Contains(entry.UpstreamStatus, "504")
+}
+
+func (r *logReader) readNewLines() []string {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return []string{}
+	}
+
+	lines := []string{}
+	if r.info != nil && !os.SameFile(r.info, info) {
+		// The file has been rotated, finish reading the old file first
+		if rotatedInfo, err := os.Stat(r.path + ".1"); err == nil && os.SameFile(r.info, rotatedInfo) {
+			lines = append(lines, r.read(r.path+".1")...)
+		}
+		r.offset = 0
+		r.partial = ""
+	} else if info.Size() < r.offset {
+		// The file has been truncated
+		r.offset = 0
+		r.partial = ""
+	}
+	r.info = info
+
+	return append(lines, r.read(r.path)...)
+}
+
+func (r *logReader) read(path string) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return []string{}
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(r.offset, io.SeekStart); err != nil {
+		return []string{}
+	}
+	data, err := io.ReadAll(file)
+	if err != nil || len(data) == 0 {
+		return []string{}
+	}
+	r.offset += int64(len(data))
+
+	// Only complete lines are processed, the rest is kept until Nginx finishes writing it
+	lines := strings.Split(r.partial+string(data), "\n")
+	r.partial = lines[len(lines)-1]
+
+	return lines[:len(lines)-1]
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// WritePrometheus writes the metrics in the Prometheus text exposition format
+// https://prometheus.io/docs/instrumenting/exposition_formats/
+func WritePrometheus(w io.Writer, allSeries []Series) {
+	slices.SortFunc(allSeries, func(a, b Series) int {
+		return cmp.Or(cmp.Compare(a.App, b.App), cmp.Compare(a.Domain, b.Domain))
+	})
+
+	writeHeader(w, "novus_http_requests_total", "counter", "Number of requests proxied by Novus, partitioned by status class.")
+	for _, series := range allSeries {
+		classes := make([]string, 0, len(series.StatusClasses))
+		for class := range series.StatusClasses {
+			classes = append(classes, class)
+		}
+		slices.Sort(classes)
+
+		for _, class := range classes {
+			fmt.Fprintf(w, "novus_http_requests_total{%s,status_class=\"%s\"} %d\n", labels(series), class, series.StatusClasses[class])
+		}
+	}
+
+	writeHeader(w, "novus_http_response_bytes_total", "counter", "Number of bytes sent to the clients.")
+	for _, series := range allSeries {
+		fmt.Fprintf(w, "novus_http_response_bytes_total{%s} %d\n", labels(series), series.ResponseBytes)
+	}
+
+	writeHeader(w, "novus_upstream_errors_total", "counter", "Number of requests that failed because the upstream was not reachable or timed out.")
+	for _, series := range allSeries {
+		fmt.Fprintf(w, "novus_upstream_errors_total{%s} %d\n", labels(series), series.UpstreamErrors)
+	}
+
+	writeHeader(w, "novus_http_request_duration_seconds", "histogram", "Time spent processing the requests, including the upstream response time.")
+	for _, series := range allSeries {
+		for i, bound := range LatencyBuckets {
+			fmt.Fprintf(w, "novus_http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels(series), strconv.FormatFloat(bound, 'f', -1, 64), series.LatencyBuckets[i])
+		}
+		fmt.Fprintf(w, "novus_http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels(series), series.LatencyBuckets[len(LatencyBuckets)])
+		fmt.Fprintf(w, "novus_http_request_duration_seconds_sum{%s} %s\n", labels(series), strconv.FormatFloat(series.LatencySum, 'f', -1, 64))
+		fmt.Fprintf(w, "novus_http_request_duration_seconds_count{%s} %d\n", labels(series), series.Requests)
+	}
+}
+
+func writeHeader(w io.Writer, name string, metricType string, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func labels(series Series) string {
+	return fmt.Sprintf("app=\"%s\",domain=\"%s\"", escapeLabel(series.App), escapeLabel(series.Domain))
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/request_log"
+)
+
+// Summary of the traffic used by `novus stats`
+type Summary struct {
+	From      time.Time
+	To        time.Time
+	Domains   []DomainStats
+	Clients   []ClientStats
+	Endpoints []EndpointStats
+}
+
+type DomainStats struct {
+	App            string
+	Domain         string
+	Requests       int
+	StatusClasses  map[string]int
+	UpstreamErrors int
+	P50            time.Duration
+	P95            time.Duration
+}
+
+type ClientStats struct {
+	RemoteAddr string
+	UserAgent  string
+	Requests   int
+}
+
+type EndpointStats struct {
+	Domain   string
+	Method   string
+	Path     string
+	Requests int
+	Average  time.Duration
+	P95      time.Duration
+}
+
+// Summarize aggregates the log entries of the given apps (app name -> entries)
+func Summarize(appEntries map[string][]request_log.Entry) Summary {
+	summary := Summary{}
+
+	domainDurations := map[seriesKey][]float64{}
+	domains := map[seriesKey]*DomainStats{}
+	clients := map[string]*ClientStats{}
+	endpointDurations := map[string][]float64{}
+	endpoints := map[string]*EndpointStats{}
+
+	for appName, entries := range appEntries {
+		for _, entry := range entries {
+			if summary.From.IsZero() || entry.Time.Before(summary.From) {
+				summary.From = entry.Time
+			}
+			if entry.Time.After(summary.To) {
+				summary.To = entry.Time
+			}
+
+			domainKey := seriesKey{app: appName, domain: entry.Host}
+			domain, exists := domains[domainKey]
+			if !exists {
+				domain = &DomainStats{App: appName, Domain: entry.Host, StatusClasses: map[string]int{}}
+				domains[domainKey] = domain
+			}
+			domain.Requests++
+			domain.StatusClasses[StatusClass(entry.Status)]++
+			if IsUpstreamError(entry) {
+				domain.UpstreamErrors++
+			}
+			domainDurations[domainKey] = append(domainDurations[domainKey], entry.Duration)
+
+			clientKey := entry.RemoteAddr + "\x00" + entry.UserAgent
+			client, exists := clients[clientKey]
+			if !exists {
+				client = &ClientStats{RemoteAddr: entry.RemoteAddr, UserAgent: entry.UserAgent}
+				clients[clientKey] = client
+			}
+			client.Requests++
+
+			path, _, _ := strings.Cut(entry.URI, "?")
+			endpointKey := entry.Host + " " + entry.Method + " " + path
+			endpoint, exists := endpoints[endpointKey]
+			if !exists {
+				endpoint = &EndpointStats{Domain: entry.Host, Method: entry.Method, Path: path}
+				endpoints[endpointKey] = endpoint
+			}
+			endpoint.Requests++
+			endpointDurations[endpointKey] = append(endpointDurations[endpointKey], entry.Duration)
+		}
+	}
+
+	for key, domain := range domains {
+		domain.P50 = percentile(domainDurations[key], 0.50)
+		domain.P95 = percentile(domainDurations[key], 0.95)
+		summary.Domains = append(summary.Domains, *domain)
+	}
+	slices.SortFunc(summary.Domains, func(a, b DomainStats) int {
+		return cmp.Or(cmp.Compare(a.App, b.App), cmp.Compare(a.Domain, b.Domain))
+	})
+
+	for _, client := range clients {
+		summary.Clients = append(summary.Clients, *client)
+	}
+	slices.SortFunc(summary.Clients, func(a, b ClientStats) int { return cmp.Compare(b.Requests, a.Requests) })
+
+	for key, endpoint := range endpoints {
+		durations := endpointDurations[key]
+		total := 0.0
+		for _, duration := range durations {
+			total += duration
+		}
+		endpoint.Average = seconds(total / float64(len(durations)))
+		endpoint.P95 = percentile(durations, 0.95)
+		summary.Endpoints = append(summary.Endpoints, *endpoint)
+	}
+	slices.SortFunc(summary.Endpoints, func(a, b EndpointStats) int { return cmp.Compare(b.P95, a.P95) })
+
+	return summary
+}
+
+// Uses the nearest-rank method
+func percentile(durations []float64, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+
+	return seconds(sorted[max(0, rank)])
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
@@ -2,53 +2,55 @@ package mkcert
 
 import (
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
-	"time"
+	"runtime"
 
-	"github.com/jozefcipa/novus/internal/logger"
-	"github.com/jozefcipa/novus/internal/sharedtypes"
+	"github.com/jozefcipa/novus/internal/fs"
 )
 
-func Configure() {
-	logger.Debugf("Initializing mkcert")
+// Novus used to issue the certificates with mkcert, its root CA can be imported
+// so the certificates stay trusted without installing a new root.
 
-	err := exec.Command("mkcert", "-install").Run()
-	if err != nil {
-		logger.Errorf("Failed to run \"mkcert -install\": %v", err)
-		os.Exit(1)
+// CARoot returns the directory where mkcert stores its root CA, the same way as `mkcert -CAROOT` does
+// (https://github.com/FiloSottile/mkcert/blob/2a46726cebac0ff4e1f133d90b4e4c42f1edf44a/main.go#L351)
+func CARoot() string {
+	if env := os.Getenv("CAROOT"); env != "" {
+		return env
 	}
-}
-
-func GenerateSSLCert(domain string, dirPath string) sharedtypes.Certificate {
-	certFilePath := filepath.Join(dirPath, "cert.pem")
-	keyFilePath := filepath.Join(dirPath, "key.pem")
 
-	params := []string{
-		"-cert-file",
-		certFilePath,
-		"-key-file",
-		keyFilePath,
-		domain,
+	var dir string
+	switch {
+	case runtime.GOOS == "darwin":
+		dir = os.Getenv("HOME")
+		if dir == "" {
+			return ""
+		}
+		dir = filepath.Join(dir, "Library", "Application Support")
+	case os.Getenv("XDG_DATA_HOME") != "":
+		dir = os.Getenv("XDG_DATA_HOME")
+	default:
+		dir = os.Getenv("HOME")
+		if dir == "" {
+			return ""
+		}
+		dir = filepath.Join(dir, ".local", "share")
 	}
 
-	err := exec.Command("mkcert", params...).Run()
-	if err != nil {
-		logger.Errorf("Failed to run \"mkcert %s\": %v", strings.Join(params, " "), err)
-		os.Exit(1)
+	return filepath.Join(dir, "mkcert")
+}
+
+// RootFiles returns the paths of the mkcert root certificate and key if both exist
+func RootFiles() (string, string, bool) {
+	caRoot := CARoot()
+	if caRoot == "" {
+		return "", "", false
 	}
 
-	return sharedtypes.Certificate{
-		CertFilePath: certFilePath,
-		KeyFilePath:  keyFilePath,
-
-		// From mkcert's code:
-		// (https://github.com/FiloSottile/mkcert/blob/2a46726cebac0ff4e1f133d90b4e4c42f1edf44a/cert.go#L59C2-L62C43)
-		//
-		// Certificates last for 2 years and 3 months, which is always less than
-		// 825 days, the limit that macOS/iOS apply to all certificates,
-		// including custom roots. See https://support.apple.com/en-us/HT210176.
-		ExpiresAt: time.Now().AddDate(2, 3, 0),
+	certFilePath := filepath.Join(caRoot, "rootCA.pem")
+	keyFilePath := filepath.Join(caRoot, "rootCA-key.pem")
+	if !fs.FileExists(certFilePath) || !fs.FileExists(keyFilePath) {
+		return "", "", false
 	}
+
+	return certFilePath, keyFilePath, true
 }
@@ -2,31 +2,30 @@ package nginx
 
 import (
 	"fmt"
-	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 
 	"github.com/jozefcipa/novus/internal/config"
+	"github.com/jozefcipa/novus/internal/dry_run"
 	"github.com/jozefcipa/novus/internal/fs"
-	"github.com/jozefcipa/novus/internal/homebrew"
 	"github.com/jozefcipa/novus/internal/logger"
 	"github.com/jozefcipa/novus/internal/novus"
 	"github.com/jozefcipa/novus/internal/paths"
 	"github.com/jozefcipa/novus/internal/ports"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/service_manager"
 	"github.com/jozefcipa/novus/internal/sharedtypes"
+	"github.com/jozefcipa/novus/internal/sudo"
+	"github.com/jozefcipa/novus/internal/transaction"
 )
 
-var NginxServersDir string
 var fileHeader string
 var corsSnippet string
 
 var Ports []string
 
 func init() {
-	// /opt/homebrew/etc/nginx/nginx.conf - main config
-	// /opt/homebrew/etc/nginx/servers/* - directory of loaded configs
-	NginxServersDir = filepath.Join(homebrew.HomebrewPrefix, "/etc/nginx/servers")
-
 	Ports = []string{"80", "443"} // HTTP, HTTPS
 
 	fileHeader = `##################################################################
@@ -45,40 +44,132 @@ func init() {
 }
 
 func Restart() {
+	if dry_run.Enabled {
+		dry_run.Record(dry_run.Action{Type: dry_run.RestartService, Target: "nginx"})
+		return
+	}
+
+	// If anything fails later on, Nginx needs to be restarted again to load the restored configuration
+	transaction.OnRollback("nginx", Restart)
+
 	nginxLoader := logger.Loadingf("Restarting Nginx")
-	homebrew.RestartService("nginx")
+	service_manager.Restart("nginx")
 
 	// Check if the restart was successful
 	isNginxRunning := IsRunning()
 	if !isNginxRunning {
 		nginxLoader.Errorf("Failed to restart Nginx.")
+		if err := Validate(); err != nil {
+			logger.Errorf(err.Error())
+		}
 		logger.Hintf("Try running one of the following commands for more info:")
-		logger.Infof("   - brew services info nginx --json\n   - nginx -t")
-		os.Exit(1)
+		logger.Infof("   - %s\n   - nginx -t", service_manager.InfoCommand("nginx"))
+		process.Exit(1)
 	}
 	nginxLoader.Checkf("Nginx restarted")
 }
 
+// Reload validates the configuration and applies it gracefully without dropping open connections.
+// If Nginx is not running yet, it will be started instead.
+func Reload() {
+	if dry_run.Enabled {
+		dry_run.Record(dry_run.Action{Type: dry_run.ReloadService, Target: "nginx"})
+		return
+	}
+
+	// If anything fails later on, Nginx needs to be reloaded again to load the restored configuration
+	transaction.OnRollback("nginx", Reload)
+
+	if err := Validate(); err != nil {
+		logger.Errorf(err.Error())
+		process.Exit(1)
+	}
+
+	if !IsRunning() {
+		logger.Debugf("Nginx is not running, starting it instead of reloading")
+		Restart()
+		return
+	}
+
+	nginxLoader := logger.Loadingf("Reloading Nginx")
+	if out, err := nginxCommand(sudo.NginxReload).CombinedOutput(); err != nil {
+		// Reloading might fail e.g. if Nginx has been started by a different user, so fall back to restarting the service
+		nginxLoader.Done()
+		logger.Debugf("Failed to reload Nginx, restarting instead: %v\n%s", err, out)
+		Restart()
+		return
+	}
+
+	nginxLoader.Checkf("Nginx reloaded")
+}
+
+// ReopenLogs tells Nginx to reopen its log files, this is needed after the logs are rotated
+func ReopenLogs() {
+	if !IsRunning() {
+		return
+	}
+
+	// Logs are also rotated by the agent in the background, where sudo can't prompt for the password
+	if service_manager.RequiresRoot("nginx") && !sudo.IsPasswordless() {
+		logger.Warnf("Nginx keeps writing to the rotated log files until it's reloaded, run \"novus trust\" to let Novus reopen them without a password")
+		return
+	}
+
+	if out, err := nginxCommand(sudo.NginxReopen).CombinedOutput(); err != nil {
+		logger.Debugf("Failed to reopen Nginx logs: %v\n%s", err, out)
+	}
+}
+
+// System units (Linux) run Nginx as root, so the pid file and the logs can be only accessed via the sudo helper
+func nginxCommand(action sudo.NginxAction) *exec.Cmd {
+	if service_manager.RequiresRoot("nginx") {
+		return sudo.NginxCommand(action)
+	}
+
+	args := map[sudo.NginxAction][]string{
+		sudo.NginxTest:   {"-t"},
+		sudo.NginxReload: {"-s", "reload"},
+		sudo.NginxReopen: {"-s", "reopen"},
+	}[action]
+
+	logger.Debugf("Running \"nginx %s\"", strings.Join(args, " "))
+	return exec.Command("nginx", args...)
+}
+
 func Stop() {
 	nginxLoader := logger.Loadingf("Stopping Nginx")
-	homebrew.StopService("nginx")
+	service_manager.Stop("nginx")
 	nginxLoader.Infof("🚫 Nginx stopped")
 }
 
 func IsRunning() bool {
-	return homebrew.IsServiceRunning("nginx")
+	return service_manager.IsRunning("nginx")
 }
 
 func CheckPortsAvailability(portsUsage ports.PortUsage) {
 	for _, port := range Ports {
 		if portUsedBy, isUsed := portsUsage[port]; isUsed && portUsedBy != "nginx" {
 			logger.Errorf("Cannot start Nginx: Port %s is already used by '%s'", port, portUsedBy)
-			os.Exit(1)
+			process.Exit(1)
 		}
 	}
 }
 
-func Configure(appConfig config.NovusConfig, sslCerts sharedtypes.DomainCertificates, appState *novus.AppState) bool {
+func Configure(appConfig config.NovusConfig, sslCerts sharedtypes.DomainCertificates) bool {
+	// Make sure Nginx loads the configs generated by Novus
+	fs.MakeDirOrExit(paths.NginxServersDir)
+	ensureIncludeFile()
+	checkWorkerAccess()
+
+	// Create request logs format config if it doesn't exist
+	// Nginx doesn't create the logs directory by itself
+	fs.MakeDirOrExit(paths.AppLogsDir(appConfig.AppName))
+	logFormatConfig := fileHeader + fs.ReadFileOrExit(filepath.Join(paths.AssetsDir, "nginx/log-format.template.conf"))
+	if readServerConfig(getLogFormatConfigName()) != logFormatConfig {
+		logger.Debugf("Generated log format Nginx config: \n\n%s", logFormatConfig)
+		writeServerConfig(getLogFormatConfigName(), logFormatConfig)
+	}
+
 	// Create default server config if it doesn't exist
 	nginxDefaultConf := readServerConfig(getDefaultConfigName())
 
@@ -87,13 +178,15 @@ func Configure(appConfig config.NovusConfig, sslCerts sharedtypes.DomainCertific
 	defaultConfig = strings.Replace(defaultConfig, "--NOVUS_HTML_DIR--", filepath.Join(paths.AssetsDir, "nginx/html"), -1)
 	defaultConfig = strings.Replace(defaultConfig, "--NOVUS_ASSETS_DIR--", filepath.Join(paths.AssetsDir, "nginx"), -1)
 	defaultConfig = strings.Replace(defaultConfig, "--NOVUS_STATE_FILE_PATH--", paths.NovusStateFilePath, -1)
+	defaultConfig = strings.Replace(defaultConfig, "--NOVUS_CA_DOWNLOAD_DIR--", paths.CADownloadDir, -1)
 	defaultConfig = strings.Replace(defaultConfig, "--NOVUS_INTERNAL_SERVER_NAME--", novus.NovusInternalDomain, -1)
 	defaultConfig = strings.Replace(defaultConfig, "--NOVUS_INDEX_SERVER_NAME--", novus.NovusIndexDomain, -1)
+	defaultConfig = strings.Replace(defaultConfig, "--NOVUS_API_ADDR--", novus.NovusAgentAddress, -1)
 
 	novusInternalDomainSSL, ok := sslCerts[novus.NovusInternalDomain]
 	if !ok {
 		logger.Errorf("Internal domain %s not found in SSL certs config\n", novus.NovusInternalDomain)
-		os.Exit(1)
+		process.Exit(1)
 	}
 
 	defaultConfig = strings.Replace(defaultConfig, "--NOVUS_INTERNAL_SSL_CERT_PATH--", novusInternalDomainSSL.CertFilePath, -1)
@@ -102,7 +195,7 @@ func Configure(appConfig config.NovusConfig, sslCerts sharedtypes.DomainCertific
 	novusIndexDomainSSL, ok := sslCerts[novus.NovusIndexDomain]
 	if !ok {
 		logger.Errorf("Internal domain %s not found in SSL certs config\n", novus.NovusIndexDomain)
-		os.Exit(1)
+		process.Exit(1)
 	}
 	defaultConfig = strings.Replace(defaultConfig, "--NOVUS_INDEX_SSL_CERT_PATH--", novusIndexDomainSSL.CertFilePath, -1)
 	defaultConfig = strings.Replace(defaultConfig, "--NOVUS_INDEX_SSL_KEY_PATH--", novusIndexDomainSSL.KeyFilePath, -1)
@@ -114,7 +207,7 @@ func Configure(appConfig config.NovusConfig, sslCerts sharedtypes.DomainCertific
 
 	// Create application server config if it doesn't exist
 	nginxAppConf := readServerConfig(getAppConfigName(appConfig.AppName))
-	newNginxAppConf := buildServerConfig(appConfig, sslCerts, appState)
+	newNginxAppConf := buildServerConfig(appConfig, sslCerts)
 
 	if nginxAppConf == "" || nginxAppConf != newNginxAppConf {
 		logger.Debugf("Generated application server Nginx config: \n\n%s", newNginxAppConf)
@@ -128,17 +221,34 @@ func Configure(appConfig config.NovusConfig, sslCerts sharedtypes.DomainCertific
 }
 
 func RemoveConfiguration(appName string) {
-	configFilePath := filepath.Join(NginxServersDir, getAppConfigName(appName))
+	configFilePath := filepath.Join(paths.NginxServersDir, getAppConfigName(appName))
 
 	logger.Debugf("Removing application server Nginx config for app %s [%s]", appName, configFilePath)
 
 	if fs.FileExists(configFilePath) {
+		transaction.TrackFile(configFilePath)
 		fs.DeleteFile(configFilePath)
 	}
 }
 
+// On Linux the server configs are stored in ~/.novus, so they have to be included in the system-wide Nginx configuration
+func ensureIncludeFile() {
+	if paths.NginxIncludeFilePath == "" {
+		return
+	}
+
+	includeConfig := fileHeader + fmt.Sprintf("include %s/*.conf;\n", paths.NginxServersDir)
+	if current, _ := fs.ReadFile(paths.NginxIncludeFilePath); current == includeConfig {
+		return
+	}
+
+	logger.Debugf("Registering Novus configs in Nginx [%s]", paths.NginxIncludeFilePath)
+	transaction.TrackPrivilegedFile(paths.NginxIncludeFilePath)
+	sudo.WriteFileOrExit(paths.NginxIncludeFilePath, includeConfig)
+}
+
 func readServerConfig(fileName string) string {
-	path := filepath.Join(NginxServersDir, fileName)
+	path := filepath.Join(paths.NginxServersDir, fileName)
 	logger.Debugf("Reading Nginx config [%s]", path)
 
 	// If file doesn't exist (an error is thrown) just return an empty string and we'll create a new config later
@@ -148,19 +258,17 @@ func readServerConfig(fileName string) string {
 }
 
 func writeServerConfig(fileName string, serverConfig string) {
-	path := filepath.Join(NginxServersDir, fileName)
+	path := filepath.Join(paths.NginxServersDir, fileName)
 	logger.Debugf("Updating Nginx config [%s]", path)
 
+	transaction.TrackFile(path)
 	fs.WriteFileOrExit(path, serverConfig)
 }
 
-func buildServerConfig(appConfig config.NovusConfig, sslCerts sharedtypes.DomainCertificates, appState *novus.AppState) string {
+func buildServerConfig(appConfig config.NovusConfig, sslCerts sharedtypes.DomainCertificates) string {
 	// Read template file
 	serverConfigTemplate := fs.ReadFileOrExit(filepath.Join(paths.AssetsDir, "nginx/server.template.conf"))
 
-	// Update routes in state
-	appState.Routes = appConfig.Routes
-
 	// Iterate through all the routes and generate Nginx config
 	serverConfig := fileHeader
 	for _, route := range appConfig.Routes {
@@ -172,6 +280,8 @@ func buildServerConfig(appConfig config.NovusConfig, sslCerts sharedtypes.Domain
 		routeConfig = strings.ReplaceAll(routeConfig, "--NOVUS_HTML_DIR--", filepath.Join(paths.AssetsDir, "nginx/html"))
 		routeConfig = strings.ReplaceAll(routeConfig, "--SSL_CERT_PATH--", sslCert.CertFilePath)
 		routeConfig = strings.ReplaceAll(routeConfig, "--SSL_KEY_PATH--", sslCert.KeyFilePath)
+		routeConfig = strings.ReplaceAll(routeConfig, "--ACCESS_LOG_PATH--", paths.AppAccessLogFilePath(appConfig.AppName))
+		routeConfig = strings.ReplaceAll(routeConfig, "--ERROR_LOG_PATH--", paths.AppErrorLogFilePath(appConfig.AppName))
 
 		// Add CORS headers if enabled
 		if route.Cors {
@@ -186,6 +296,11 @@ func buildServerConfig(appConfig config.NovusConfig, sslCerts sharedtypes.Domain
 	return serverConfig
 }
 
+// Nginx loads the configs in the alphabetical order and the log format must be defined before it's used
+func getLogFormatConfigName() string {
+	return "novus-00-log-format.conf"
+}
+
 func getDefaultConfigName() string {
 	return "novus-default.conf"
 }
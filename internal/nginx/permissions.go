@@ -0,0 +1,74 @@
+package nginx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/service_manager"
+)
+
+var workerAccessChecked bool
+
+// The system Nginx unit (Linux) runs the worker processes as an unprivileged user (e.g. www-data).
+// The configs, certificates and logs are opened by the master process running as root,
+// but the workers serve the Novus pages and downloads directly from the Novus directories,
+// so all of them (and their parent directories) must be accessible by other users.
+func checkWorkerAccess() {
+	if workerAccessChecked || paths.NginxIncludeFilePath == "" || !service_manager.RequiresRoot("nginx") {
+		return
+	}
+	workerAccessChecked = true
+
+	servedPaths := []string{
+		filepath.Join(paths.AssetsDir, "nginx"),
+		paths.NovusStateFilePath,
+		paths.CADownloadDir,
+	}
+
+	hints := []string{}
+	for _, path := range servedPaths {
+		if inaccessiblePath, mode, ok := findInaccessiblePath(path); !ok {
+			hints = append(hints, fmt.Sprintf("Allow other users to access %s (\"chmod %s %s\") or add the Nginx user to its group.", inaccessiblePath, mode, inaccessiblePath))
+		}
+	}
+	if len(hints) == 0 {
+		return
+	}
+
+	logger.Warnf("Nginx workers can't read the Novus files, error pages and the index.novus and CA download pages will return 403 Forbidden")
+	for _, hint := range slices.Compact(hints) {
+		logger.Hintf(hint)
+	}
+}
+
+// Returns the first path component that can't be accessed by other users and the permissions it needs.
+// Paths that don't exist yet are skipped, they are created with the default permissions.
+func findInaccessiblePath(path string) (string, string, bool) {
+	parentDirs := []string{}
+	for dir := filepath.Dir(path); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		parentDirs = append(parentDirs, dir)
+	}
+	slices.Reverse(parentDirs)
+
+	for _, dir := range parentDirs {
+		if info, err := os.Stat(dir); err == nil && info.Mode().Perm()&0001 == 0 {
+			return dir, "o+x", false
+		}
+	}
+
+	info, err := os.Stat(path)
+	switch {
+	case err != nil:
+		return "", "", true
+	case info.IsDir() && info.Mode().Perm()&0005 != 0005:
+		return path, "o+rx", false
+	case !info.IsDir() && info.Mode().Perm()&0004 == 0:
+		return path, "o+r", false
+	}
+
+	return "", "", true
+}
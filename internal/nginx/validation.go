@@ -0,0 +1,81 @@
+package nginx
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/sudo"
+)
+
+// Matches the location of the error in the `nginx -t` output,
+// e.g. "nginx: [emerg] invalid port in upstream "localhost:abc" in /opt/homebrew/etc/nginx/servers/novus-app-api.conf:25"
+var configErrorLocationRegex = regexp.MustCompile(`in (\S+\.conf):(\d+)`)
+var serverNameRegex = regexp.MustCompile(`^\s*server_name\s+([^;\s]+)`)
+var appConfigNameRegex = regexp.MustCompile(`^novus-app-(.+)\.conf$`)
+
+type ConfigValidationError struct {
+	Output string
+	// Novus route (domain) that contains the error, if it could be found
+	Domain  string
+	AppName string
+}
+
+func (e *ConfigValidationError) Error() string {
+	message := fmt.Sprintf("Nginx configuration is invalid:\n   %s", strings.ReplaceAll(e.Output, "\n", "\n   "))
+
+	if e.Domain != "" {
+		message += fmt.Sprintf("\n   The error was found in the route [%s] (app \"%s\")", e.Domain, e.AppName)
+	}
+
+	return message
+}
+
+// Runs `nginx -t` to check that the generated configuration can be loaded
+func Validate() error {
+	out, err := nginxCommand(sudo.NginxTest).CombinedOutput()
+	if err == nil {
+		logger.Debugf("Nginx configuration is valid")
+		return nil
+	}
+
+	output := strings.TrimSpace(string(out))
+	validationErr := &ConfigValidationError{Output: output}
+
+	// Try to find the Novus route that caused the error
+	if match := configErrorLocationRegex.FindStringSubmatch(output); match != nil {
+		configPath := match[1]
+		line, _ := strconv.Atoi(match[2])
+
+		if appMatch := appConfigNameRegex.FindStringSubmatch(filepath.Base(configPath)); appMatch != nil {
+			validationErr.AppName = appMatch[1]
+			validationErr.Domain = findServerNameAtLine(configPath, line)
+		}
+	}
+
+	return validationErr
+}
+
+// Finds the closest `server_name` directive defined before the given line
+func findServerNameAtLine(configPath string, line int) string {
+	file, err := os.Open(configPath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	serverName := ""
+	scanner := bufio.NewScanner(file)
+	for currentLine := 1; scanner.Scan() && currentLine <= line; currentLine++ {
+		if match := serverNameRegex.FindStringSubmatch(scanner.Text()); match != nil {
+			serverName = match[1]
+		}
+	}
+
+	return serverName
+}
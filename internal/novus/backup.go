@@ -0,0 +1,192 @@
+package novus
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/dry_run"
+	"github.com/jozefcipa/novus/internal/fs"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/process"
+	"golang.org/x/term"
+)
+
+// How many previous versions of the state file are kept
+const MaxStateBackups = 10
+
+const backupTimeFormat = "20060102-150405.000"
+
+type StateBackup struct {
+	Path      string
+	CreatedAt time.Time
+	Size      int64
+	// Number of apps in the backup (excluding the internal ones), only set if the backup is valid
+	AppsCount int
+	Valid     bool
+}
+
+// ListStateBackups returns all backups of the state file, the newest first
+func ListStateBackups() []StateBackup {
+	entries, err := os.ReadDir(paths.NovusStateBackupsDir)
+	if err != nil {
+		return []StateBackup{}
+	}
+
+	backups := []StateBackup{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, "novus-") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+
+		createdAt, err := time.ParseInLocation(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, "novus-"), ".json"), time.Local)
+		if err != nil {
+			continue
+		}
+
+		backup := StateBackup{Path: filepath.Join(paths.NovusStateBackupsDir, name), CreatedAt: createdAt}
+		if info, err := entry.Info(); err == nil {
+			backup.Size = info.Size()
+		}
+		if backupState, err := readStateFile(backup.Path); err == nil {
+			backup.Valid = true
+			for appName := range backupState.Apps {
+				if appName != NovusInternalAppName {
+					backup.AppsCount++
+				}
+			}
+		}
+
+		backups = append(backups, backup)
+	}
+
+	slices.SortFunc(backups, func(a, b StateBackup) int { return b.CreatedAt.Compare(a.CreatedAt) })
+
+	return backups
+}
+
+// RestoreStateBackup replaces the state file with the given backup and loads it
+func RestoreStateBackup(backup StateBackup) error {
+	content, err := os.ReadFile(backup.Path)
+	if err != nil {
+		return err
+	}
+	if _, err := readStateFile(backup.Path); err != nil {
+		return fmt.Errorf("backup is not valid: %v", err)
+	}
+
+	// Keep the current state as a backup too, so the restore can be reverted
+	backupStateFile()
+	fs.WriteFileAtomicOrExit(paths.NovusStateFilePath, string(content))
+	logger.Debugf("State restored from backup [%s]", backup.Path)
+
+	ReloadState()
+	return nil
+}
+
+// Copies the current state file to the backups directory (only if it's valid and changed since the last backup)
+func backupStateFile() {
+	content, err := os.ReadFile(paths.NovusStateFilePath)
+	if err != nil || !json.Valid(content) {
+		return
+	}
+
+	backups := ListStateBackups()
+	if len(backups) > 0 {
+		if latest, err := os.ReadFile(backups[0].Path); err == nil && string(latest) == string(content) {
+			return
+		}
+	}
+
+	fs.MakeDirOrExit(paths.NovusStateBackupsDir)
+	backupPath := filepath.Join(paths.NovusStateBackupsDir, fmt.Sprintf("novus-%s.json", time.Now().Format(backupTimeFormat)))
+	logger.Debugf("Backing up state file [%s]", backupPath)
+	if err := os.WriteFile(backupPath, content, 0644); err != nil {
+		logger.Debugf("Failed to back up state file: %v", err)
+		return
+	}
+
+	pruneStateBackups()
+}
+
+// Removes the oldest backups
+func pruneStateBackups() {
+	backups := ListStateBackups()
+	if len(backups) <= MaxStateBackups {
+		return
+	}
+
+	for _, backup := range backups[MaxStateBackups:] {
+		logger.Debugf("Removing old state backup [%s]", backup.Path)
+		os.Remove(backup.Path)
+	}
+}
+
+func readStateFile(path string) (NovusState, error) {
+	var fileState NovusState
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fileState, err
+	}
+	// Backups might have been created by another Novus version, newer schema versions are refused
+	content, _, err = migrateState(content)
+	if err != nil {
+		return fileState, err
+	}
+	if err := json.Unmarshal(content, &fileState); err != nil {
+		return fileState, err
+	}
+	if fileState.Apps == nil {
+		return fileState, fmt.Errorf("no apps found")
+	}
+
+	return fileState, nil
+}
+
+// Called when the state file can't be parsed, offers restoring the newest valid backup
+func recoverCorruptedState(parseErr error) {
+	logger.Errorf("Corrupted state file %s\n   Reason: %v", paths.NovusStateFilePath, parseErr)
+
+	backupIdx := slices.IndexFunc(ListStateBackups(), func(backup StateBackup) bool { return backup.Valid })
+	if backupIdx == -1 {
+		logger.Hintf("No valid backup found in %s. Fix or delete the state file and run \"novus serve\" in your apps again.", paths.NovusStateBackupsDir)
+		process.Exit(1)
+	}
+	backup := ListStateBackups()[backupIdx]
+
+	if dry_run.Enabled {
+		logger.Hintf("Run \"novus state restore\" to restore the newest backup from %s.", backup.CreatedAt.Format(time.DateTime))
+		process.Exit(1)
+	}
+
+	// Don't block if nobody can answer (e.g. when called by the Novus agent)
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		logger.Hintf("Run \"novus state restore\" to restore the newest backup from %s.", backup.CreatedAt.Format(time.DateTime))
+		process.Exit(1)
+	}
+
+	fmt.Printf("%sDo you want to restore the newest backup from %s (%d apps)? [Y/n]: %s", logger.GRAY, backup.CreatedAt.Format(time.DateTime), backup.AppsCount, logger.RESET)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.TrimSpace(answer) != "Y" {
+		logger.Hintf("Run \"novus state restore --list\" to see all backups.")
+		process.Exit(1)
+	}
+
+	// Keep the corrupted file for inspection
+	corruptedPath := fmt.Sprintf("%s.corrupted-%s", paths.NovusStateFilePath, time.Now().Format(backupTimeFormat))
+	if err := os.Rename(paths.NovusStateFilePath, corruptedPath); err == nil {
+		logger.Infof("Corrupted state file moved to %s", corruptedPath)
+	}
+
+	content := fs.ReadFileOrExit(backup.Path)
+	fs.WriteFileAtomicOrExit(paths.NovusStateFilePath, content)
+	logger.Checkf("State restored from backup %s", filepath.Base(backup.Path))
+}
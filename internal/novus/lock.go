@@ -0,0 +1,106 @@
+package novus
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/dry_run"
+	"github.com/jozefcipa/novus/internal/fs"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/process"
+)
+
+// How long a command waits for another Novus command to release the state lock
+var LockTimeout = 60 * time.Second
+
+const lockPollInterval = 100 * time.Millisecond
+
+var lockFile *os.File
+
+// Lock acquires an exclusive lock of the Novus state, so that concurrently running commands
+// don't overwrite each other's changes (state file, Nginx and DNSMasq configs).
+// The state is (re)loaded once the lock is acquired, as it might have been changed by the previous lock holder.
+// The lock is released by calling Unlock() or automatically when the process exits.
+func Lock() {
+	if lockFile != nil {
+		return
+	}
+
+	// A dry run doesn't create anything, on a fresh install there's no state directory yet and no state to protect
+	if dry_run.Enabled && !fs.FileExists(paths.NovusStateDir) {
+		logger.Debugf("[dry-run] Skipping state lock, state directory doesn't exist [%s]", paths.NovusStateDir)
+		return
+	}
+
+	initStateDir()
+	file, err := os.OpenFile(paths.NovusStateLockFilePath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		logger.Errorf("Failed to open state lock file %s\n   Reason: %v", paths.NovusStateLockFilePath, err)
+		process.Exit(1)
+	}
+
+	if err := tryLock(file); err != nil {
+		waitForLock(file)
+	}
+
+	// Store who holds the lock, so other commands can show it while waiting
+	file.Truncate(0)
+	file.WriteAt([]byte(fmt.Sprintf("%d %s", os.Getpid(), strings.Join(os.Args, " "))), 0)
+
+	logger.Debugf("State lock acquired [%s]", paths.NovusStateLockFilePath)
+	lockFile = file
+
+	if stateLoaded {
+		ReloadState()
+	}
+}
+
+// Unlock releases the state lock, so other commands can continue
+func Unlock() {
+	if lockFile == nil {
+		return
+	}
+
+	lockFile.Truncate(0)
+	syscall.Flock(int(lockFile.Fd()), syscall.LOCK_UN)
+	lockFile.Close()
+	lockFile = nil
+
+	logger.Debugf("State lock released")
+}
+
+func IsLocked() bool {
+	return lockFile != nil
+}
+
+func tryLock(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+}
+
+func waitForLock(file *os.File) {
+	holder := "another Novus command"
+	if content, err := os.ReadFile(paths.NovusStateLockFilePath); err == nil {
+		if pid, command, found := strings.Cut(strings.TrimSpace(string(content)), " "); found {
+			holder = fmt.Sprintf("\"%s\" (pid %s)", command, pid)
+		}
+	}
+
+	loader := logger.Loadingf("Waiting for %s to finish", holder)
+	deadline := time.Now().Add(LockTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(lockPollInterval)
+		if tryLock(file) == nil {
+			loader.Done()
+			return
+		}
+	}
+
+	loader.Done()
+	logger.Errorf("Timed out after %s waiting for %s to finish", LockTimeout, holder)
+	logger.Hintf("Try again once it finishes, or increase the timeout with --lock-timeout")
+	process.Exit(1)
+}
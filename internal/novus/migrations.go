@@ -0,0 +1,111 @@
+package novus
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jozefcipa/novus/internal/logger"
+)
+
+// Version of the state file structure, increase it whenever a migration is added
+const CurrentSchemaVersion = 1
+
+// Migration transforms the state file from the previous schema version to `version`.
+// It works with the raw JSON, because older state files might not match the current structs anymore.
+type migration struct {
+	version     int
+	description string
+	migrate     func(rawState map[string]any) error
+}
+
+// Ordered list of all migrations, the state is migrated one version at a time
+var migrations = []migration{
+	{
+		version:     1,
+		description: "introduce schema version and fill in missing fields",
+		migrate: func(rawState map[string]any) error {
+			for _, key := range []string{"dnsFiles", "apps"} {
+				if _, ok := rawState[key].(map[string]any); !ok {
+					rawState[key] = map[string]any{}
+				}
+			}
+
+			for appName, rawApp := range rawState["apps"].(map[string]any) {
+				app, ok := rawApp.(map[string]any)
+				if !ok {
+					return fmt.Errorf("app \"%s\" is not an object", appName)
+				}
+				if _, ok := app["sslCertificates"].(map[string]any); !ok {
+					app["sslCertificates"] = map[string]any{}
+				}
+				if _, ok := app["routes"].([]any); !ok {
+					app["routes"] = []any{}
+				}
+				if status, _ := app["appStatus"].(string); status == "" {
+					app["appStatus"] = string(APP_ACTIVE)
+				}
+			}
+
+			return nil
+		},
+	},
+}
+
+// StateDowngradeError is returned if the state file has been written by a newer Novus version
+type StateDowngradeError struct {
+	SchemaVersion int
+	NovusVersion  string
+}
+
+func (e *StateDowngradeError) Error() string {
+	return fmt.Sprintf(
+		"State file has been created by a newer Novus version (%s, schema version %d), this version only supports schema version %d",
+		e.NovusVersion, e.SchemaVersion, CurrentSchemaVersion,
+	)
+}
+
+// Returns the schema version of the raw state, state files created before versioning was introduced are version 0
+func schemaVersionOf(rawState map[string]any) int {
+	version, ok := rawState["schemaVersion"].(float64)
+	if !ok {
+		return 0
+	}
+	return int(version)
+}
+
+// migrateState upgrades the state file content to the current schema version.
+// Returns the migrated content and whether any migration has been applied.
+func migrateState(content []byte) ([]byte, bool, error) {
+	rawState := map[string]any{}
+	if err := json.Unmarshal(content, &rawState); err != nil {
+		return nil, false, err
+	}
+
+	schemaVersion := schemaVersionOf(rawState)
+	if schemaVersion > CurrentSchemaVersion {
+		novusVersion, _ := rawState["version"].(string)
+		return nil, false, &StateDowngradeError{SchemaVersion: schemaVersion, NovusVersion: novusVersion}
+	}
+	if schemaVersion == CurrentSchemaVersion {
+		return content, false, nil
+	}
+
+	for _, m := range migrations {
+		if m.version <= schemaVersion {
+			continue
+		}
+
+		logger.Debugf("Migrating state to schema version %d (%s)", m.version, m.description)
+		if err := m.migrate(rawState); err != nil {
+			return nil, false, fmt.Errorf("migration to schema version %d failed: %v", m.version, err)
+		}
+		rawState["schemaVersion"] = m.version
+	}
+
+	migrated, err := json.Marshal(rawState)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return migrated, true, nil
+}
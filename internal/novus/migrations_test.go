@@ -0,0 +1,114 @@
+package novus
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	content, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture %s: %v", name, err)
+	}
+
+	return content
+}
+
+func unmarshalRaw(t *testing.T, content []byte) map[string]any {
+	t.Helper()
+
+	rawState := map[string]any{}
+	if err := json.Unmarshal(content, &rawState); err != nil {
+		t.Fatalf("Failed to parse state: %v", err)
+	}
+
+	return rawState
+}
+
+func TestMigrationsAreOrdered(t *testing.T) {
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Errorf("Migration at index %d has version %d, expected %d", i, m.version, i+1)
+		}
+	}
+
+	if len(migrations) != CurrentSchemaVersion {
+		t.Errorf("Found %d migrations, but CurrentSchemaVersion is %d", len(migrations), CurrentSchemaVersion)
+	}
+}
+
+func TestMigrateStateFromV0(t *testing.T) {
+	content, migrated, err := migrateState(readFixture(t, "state-v0.json"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !migrated {
+		t.Errorf("Expected the state to be migrated")
+	}
+
+	expected := unmarshalRaw(t, readFixture(t, "state-v0-migrated.json"))
+	if actual := unmarshalRaw(t, content); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Migrated state doesn't match the fixture\n got: %v\nwant: %v", actual, expected)
+	}
+
+	// The migrated state must be loadable into the current structs
+	var state NovusState
+	if err := json.Unmarshal(content, &state); err != nil {
+		t.Fatalf("Failed to parse the migrated state: %v", err)
+	}
+	if state.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("Expected schema version %d, got %d", CurrentSchemaVersion, state.SchemaVersion)
+	}
+	if state.Apps["shop"].Status != APP_ACTIVE {
+		t.Errorf("Expected missing app status to default to %s, got %s", APP_ACTIVE, state.Apps["shop"].Status)
+	}
+}
+
+func TestMigrateStateCurrentVersion(t *testing.T) {
+	fixture := readFixture(t, "state-v1.json")
+
+	content, migrated, err := migrateState(fixture)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if migrated {
+		t.Errorf("Expected the state not to be migrated")
+	}
+	if string(content) != string(fixture) {
+		t.Errorf("Expected the state to be unchanged\n got: %s\nwant: %s", content, fixture)
+	}
+}
+
+func TestMigrateStateRefusesDowngrade(t *testing.T) {
+	_, _, err := migrateState(readFixture(t, "state-future.json"))
+
+	var downgradeErr *StateDowngradeError
+	if !errors.As(err, &downgradeErr) {
+		t.Fatalf("Expected StateDowngradeError, got %v", err)
+	}
+	if downgradeErr.SchemaVersion != 99 || downgradeErr.NovusVersion != "9.0.0" {
+		t.Errorf("Unexpected error details: %+v", downgradeErr)
+	}
+}
+
+func TestReadStateFileMigratesBackups(t *testing.T) {
+	state, err := readStateFile(filepath.Join("testdata", "state-v0.json"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if state.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("Expected schema version %d, got %d", CurrentSchemaVersion, state.SchemaVersion)
+	}
+
+	_, err = readStateFile(filepath.Join("testdata", "state-future.json"))
+	var downgradeErr *StateDowngradeError
+	if !errors.As(err, &downgradeErr) {
+		t.Errorf("Expected StateDowngradeError for a backup created by a newer version, got %v", err)
+	}
+}
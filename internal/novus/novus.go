@@ -2,20 +2,27 @@ package novus
 
 import (
 	"encoding/json"
-	"os"
+	"errors"
 
+	"github.com/jozefcipa/novus/internal/dry_run"
 	"github.com/jozefcipa/novus/internal/fs"
 	"github.com/jozefcipa/novus/internal/logger"
 	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/process"
 	"github.com/jozefcipa/novus/internal/sharedtypes"
+	"github.com/jozefcipa/novus/internal/transaction"
 )
 
 var state NovusState
 var stateLoaded bool
+var stateMigrated bool
 
 const NovusInternalDomain = "internal.novus"
 const NovusIndexDomain = "index.novus"
 
+// Novus agent serving the control API only listens on localhost, Nginx proxies https://internal.novus/api/* to it
+const NovusAgentAddress = "127.0.0.1:5054"
+
 // This is used to store internal routes used by Novus itself
 const NovusInternalAppName = "_novus"
 
@@ -40,14 +47,36 @@ func loadState() {
 	if err != nil {
 		logger.Debugf("State file not found. Creating a new one...")
 		state = NovusState{
-			DnsFiles: map[string]*DnsFiles{},
-			Apps:     map[string]*AppState{},
+			SchemaVersion: CurrentSchemaVersion,
+			DnsFiles:      map[string]*DnsFiles{},
+			Apps:          map[string]*AppState{},
 		}
 	} else {
-		// Otherwise parse the state file
-		if err := json.Unmarshal([]byte(file), &state); err != nil {
-			logger.Errorf("Corrupted state file.\n%v", err)
-			os.Exit(1)
+		// Otherwise upgrade the state file if it's been created by an older Novus version
+		content, migrated, err := migrateState([]byte(file))
+		var downgradeErr *StateDowngradeError
+		if errors.As(err, &downgradeErr) {
+			logger.Errorf(downgradeErr.Error())
+			logger.Hintf("Upgrade Novus (\"brew upgrade novus\") or restore an older state file by running \"novus state restore --list\".")
+			process.Exit(1)
+		}
+		if err == nil && migrated {
+			logger.Debugf("State file migrated to schema version %d", CurrentSchemaVersion)
+			// Keep the original file, so it can be restored if anything goes wrong
+			backupStateFile()
+			stateMigrated = true
+		}
+		if err == nil {
+			err = json.Unmarshal(content, &state)
+		}
+
+		// Parse the state file
+		if err != nil {
+			// Offer restoring a backup, exits if not possible
+			recoverCorruptedState(err)
+			state = NovusState{}
+			loadState()
+			return
 		}
 	}
 
@@ -68,6 +97,11 @@ func loadState() {
 	stateLoaded = true
 }
 
+// IsMigrated returns true if the loaded state has been upgraded to the current schema version and should be saved
+func IsMigrated() bool {
+	return stateMigrated
+}
+
 func GetState() *NovusState {
 	// If state hasn't been loaded yet, load it now
 	if !stateLoaded {
@@ -77,6 +111,27 @@ func GetState() *NovusState {
 	return &state
 }
 
+// ReloadState reads the state file again, discarding the in-memory copy
+// This is used by long-running processes (e.g. Novus agent) as the state might be changed by other Novus commands
+func ReloadState() *NovusState {
+	state = NovusState{}
+	loadState()
+
+	return &state
+}
+
+// TryReloadState works like ReloadState, but returns an error instead of prompting for a backup restore if the state file is corrupted.
+// The previously loaded state is kept in that case. This is used by the dashboard, which can't show prompts in the raw terminal mode, and by the agent running in the background.
+func TryReloadState() (*NovusState, error) {
+	if fs.FileExists(paths.NovusStateFilePath) {
+		if _, err := readStateFile(paths.NovusStateFilePath); err != nil {
+			return &state, err
+		}
+	}
+
+	return ReloadState(), nil
+}
+
 func GetAppState(appName string) (*AppState, bool) {
 	appState, exists := GetState().Apps[appName]
 	return appState, exists
@@ -105,17 +160,28 @@ func RemoveAppState(appName string) {
 }
 
 func SaveState() {
+	if dry_run.Enabled {
+		logger.Debugf("[dry-run] Skipping saving novus state")
+		return
+	}
+
 	// Validate config before saving it
 	state.validate()
+	state.SchemaVersion = CurrentSchemaVersion
 
 	// Encode JSON
 	jsonState, err := json.MarshalIndent(state, "", "    ")
 	if err != nil {
 		logger.Errorf("Failed to save state file\n%v", err)
-		os.Exit(1)
+		process.Exit(1)
 	}
 
+	// Keep the previous version, so it can be restored if needed
+	backupStateFile()
+
 	// Save file
 	logger.Debugf("Saving novus state [%s]", paths.NovusStateFilePath)
-	fs.WriteFileOrExit(paths.NovusStateFilePath, string(jsonState))
+	transaction.TrackFile(paths.NovusStateFilePath)
+	fs.WriteFileAtomicOrExit(paths.NovusStateFilePath, string(jsonState))
+	stateMigrated = false
 }
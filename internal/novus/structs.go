@@ -1,10 +1,9 @@
 package novus
 
 import (
-	"os"
-
 	"github.com/go-playground/validator/v10"
 	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/process"
 	"github.com/jozefcipa/novus/internal/sharedtypes"
 	"github.com/jozefcipa/novus/internal/validation"
 )
@@ -33,8 +32,11 @@ type DnsFiles struct {
 }
 
 type NovusState struct {
+	// Version of Novus that saved the state
 	Version string `json:"version"`
-	DNSMasq struct {
+	// Version of the state structure, see migrations.go
+	SchemaVersion int `json:"schemaVersion"`
+	DNSMasq       struct {
 		Port string `json:"port" validate:"required,hostname_port"`
 	} `json:"dnsMasq"`
 	// Track files that we create for DNS
@@ -57,14 +59,14 @@ func (state *NovusState) validate() {
 		err := validate.Struct(appState)
 		if err != nil {
 			logger.Errorf("Novus state file is corrupted.\n\n%s", err.(validator.ValidationErrors))
-			os.Exit(1)
+			process.Exit(1)
 		}
 
 		for _, sslCerts := range appState.SSLCertificates {
 			err := validate.Struct(sslCerts)
 			if err != nil {
 				logger.Errorf("Novus state file is corrupted.\n\n%s", err.(validator.ValidationErrors))
-				os.Exit(1)
+				process.Exit(1)
 			}
 		}
 	}
@@ -0,0 +1,26 @@
+package paths
+
+import (
+	"path/filepath"
+
+	"github.com/jozefcipa/novus/internal/logger"
+)
+
+// Used to store PID and log files of Novus background processes (~/.novus/run)
+var NovusRunDir string
+
+// Token used to authenticate requests to the Novus control API (~/.novus/api-token)
+var NovusAPITokenFilePath string
+
+func resolveAgentDirs() {
+	NovusRunDir = filepath.Join(NovusStateDir, "run")
+	NovusAPITokenFilePath = filepath.Join(NovusStateDir, "api-token")
+
+	logger.Debugf(
+		"Agent paths resolved.\n"+
+			"\tNovusRunDir = %s\n"+
+			"\tNovusAPITokenFilePath = %s",
+		NovusRunDir,
+		NovusAPITokenFilePath,
+	)
+}
@@ -1,3 +1,32 @@
 package paths
 
-const DNSResolverDir = "/etc/resolver"
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+
+	"github.com/jozefcipa/novus/internal/logger"
+)
+
+// Directory of the system DNS resolver configs for each TLD.
+// macOS reads /etc/resolver/<tld> files, on Linux systemd-resolved drop-ins are used.
+var DNSResolverDir string
+
+func resolveDNSDirs() {
+	switch runtime.GOOS {
+	case "linux":
+		DNSResolverDir = "/etc/systemd/resolved.conf.d"
+	default:
+		DNSResolverDir = "/etc/resolver"
+	}
+
+	logger.Debugf("DNS paths resolved.\n\tDNSResolverDir = %s", DNSResolverDir)
+}
+
+func DNSResolverFilePath(tld string) string {
+	if runtime.GOOS == "linux" {
+		return filepath.Join(DNSResolverDir, fmt.Sprintf("novus-%s.conf", tld))
+	}
+
+	return filepath.Join(DNSResolverDir, tld)
+}
@@ -0,0 +1,28 @@
+package paths
+
+import (
+	"path/filepath"
+
+	"github.com/jozefcipa/novus/internal/logger"
+)
+
+// Used to store request logs written by Nginx, each app has its own directory (~/.novus/logs/<app>)
+var NovusLogsDir string
+
+func resolveLogsDirs() {
+	NovusLogsDir = filepath.Join(NovusStateDir, "logs")
+
+	logger.Debugf("Logs paths resolved.\n\tNovusLogsDir = %s", NovusLogsDir)
+}
+
+func AppLogsDir(appName string) string {
+	return filepath.Join(NovusLogsDir, appName)
+}
+
+func AppAccessLogFilePath(appName string) string {
+	return filepath.Join(AppLogsDir(appName), "access.log")
+}
+
+func AppErrorLogFilePath(appName string) string {
+	return filepath.Join(AppLogsDir(appName), "error.log")
+}
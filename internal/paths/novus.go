@@ -7,6 +7,7 @@ import (
 
 	"github.com/jozefcipa/novus/internal/homebrew"
 	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/process"
 )
 
 // User home directory, in which we store the Novus state (~/)
@@ -24,12 +25,24 @@ var NovusStateDir string
 // Configuration state file
 var NovusStateFilePath string
 
+// Previous versions of the state file (~/.novus/backups)
+var NovusStateBackupsDir string
+
+// Global user preferences, e.g. which DNS server to use (~/.novus/settings.yml)
+var NovusSettingsFilePath string
+
+// Journal of all commands that changed the routing, used by `novus history` and `novus undo`
+var NovusHistoryFilePath string
+
+// Lock file preventing multiple Novus commands from changing the state at the same time
+var NovusStateLockFilePath string
+
 func resolveNovusDirs() {
 	// Home dir
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		logger.Errorf("Failed to get user home directory%s\n   Reason: %v", err)
-		os.Exit(1)
+		process.Exit(1)
 	}
 	UserHomeDir = homeDir
 
@@ -37,14 +50,14 @@ func resolveNovusDirs() {
 	currentDir, err := os.Getwd()
 	if err != nil {
 		logger.Errorf("Failed to get current working directory%s\n   Reason: %v", err)
-		os.Exit(1)
+		process.Exit(1)
 	}
 
 	// Novus executable
 	executablePath, err := os.Executable()
 	if err != nil {
 		logger.Errorf("Failed to get novus binary directory\n   Reason: %v", err)
-		os.Exit(1)
+		process.Exit(1)
 	}
 
 	// Assets dir is relative to the executable path
@@ -54,15 +67,15 @@ func resolveNovusDirs() {
 		// .
 		// ├── assets/
 		AssetsDir = filepath.Join(currentDir, "assets")
-	} else if strings.Contains(executablePath, homebrew.HomebrewPrefix) {
+	} else if homebrew.IsInstalled() && strings.Contains(executablePath, homebrew.Prefix()) {
 		// If running via Homebrew, the binary is in the Homebrew prefix directory
 		// .
-		// ├── {homebrew.HomebrewPrefix}/opt/
+		// ├── {homebrew.Prefix()}/opt/
 		// │   └── novus/
 		// │       ├── bin/
 		// │       │   └── novus
 		// │       └── assets/
-		AssetsDir = filepath.Join(homebrew.HomebrewPrefix, "/opt/novus/assets")
+		AssetsDir = filepath.Join(homebrew.Prefix(), "/opt/novus/assets")
 	} else {
 		// Otherwise if built locally via `make build`, the binary is in the `bin` directory
 		// .
@@ -76,6 +89,10 @@ func resolveNovusDirs() {
 	CurrentDir = currentDir
 	NovusStateDir = filepath.Join(UserHomeDir, ".novus")
 	NovusStateFilePath = filepath.Join(NovusStateDir, "novus.json")
+	NovusStateLockFilePath = filepath.Join(NovusStateDir, "novus.lock")
+	NovusStateBackupsDir = filepath.Join(NovusStateDir, "backups")
+	NovusHistoryFilePath = filepath.Join(NovusStateDir, "history.jsonl")
+	NovusSettingsFilePath = filepath.Join(NovusStateDir, "settings.yml")
 
 	logger.Debugf(
 		"Novus paths resolved.\n"+
@@ -6,8 +6,12 @@ import (
 
 func Resolve() {
 	resolveNovusDirs()
+	resolveServicesDirs()
+	resolveDNSDirs()
 	resolveSSLCertDirs()
 	resolveSudoDirs()
+	resolveAgentDirs()
+	resolveLogsDirs()
 
 	logger.Debugf("All paths have been resolved.")
 }
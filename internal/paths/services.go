@@ -0,0 +1,52 @@
+package paths
+
+import (
+	"path/filepath"
+	"runtime"
+
+	"github.com/jozefcipa/novus/internal/homebrew"
+	"github.com/jozefcipa/novus/internal/logger"
+)
+
+// Directory of Nginx server configs generated by Novus
+var NginxServersDir string
+
+// Main DNSMasq configuration file
+var DNSMasqConfigFilePath string
+
+// Directory of DNSMasq configs generated by Novus for each TLD
+var DNSMasqConfigDir string
+
+// Files including the Novus configs in the system-wide Nginx and DNSMasq configuration.
+// Only used on Linux, Homebrew services load the configs directly from the Homebrew prefix.
+var NginxIncludeFilePath string
+var DNSMasqIncludeFilePath string
+
+func resolveServicesDirs() {
+	switch runtime.GOOS {
+	case "linux":
+		// The system configuration in /etc is owned by root, so Novus keeps its configs in ~/.novus
+		// and only registers them once via the include files (requires sudo)
+		NginxServersDir = filepath.Join(NovusStateDir, "nginx/servers")
+		DNSMasqConfigFilePath = filepath.Join(NovusStateDir, "dnsmasq/dnsmasq.conf")
+		DNSMasqConfigDir = filepath.Join(NovusStateDir, "dnsmasq/dnsmasq.d")
+		NginxIncludeFilePath = "/etc/nginx/conf.d/novus.conf"
+		DNSMasqIncludeFilePath = "/etc/dnsmasq.d/novus.conf"
+	default:
+		// /opt/homebrew/etc/nginx/nginx.conf - main config
+		// /opt/homebrew/etc/nginx/servers/* - directory of loaded configs
+		NginxServersDir = filepath.Join(homebrew.Prefix(), "/etc/nginx/servers")
+		DNSMasqConfigFilePath = filepath.Join(homebrew.Prefix(), "/etc/dnsmasq.conf")
+		DNSMasqConfigDir = filepath.Join(homebrew.Prefix(), "/etc/dnsmasq.d")
+	}
+
+	logger.Debugf(
+		"Services paths resolved.\n"+
+			"\tNginxServersDir = %s\n"+
+			"\tDNSMasqConfigFilePath = %s\n"+
+			"\tDNSMasqConfigDir = %s",
+		NginxServersDir,
+		DNSMasqConfigFilePath,
+		DNSMasqConfigDir,
+	)
+}
@@ -9,8 +9,28 @@ import (
 // Used to store SSL certificate files (~/.novus/certs)
 var SSLCertificatesDir string
 
+// Local certificate authority that signs the SSL certificates (~/.novus/ca)
+var CADir string
+var CACertFilePath string
+var CAKeyFilePath string
+
+// CA certificate exported for other devices, served on https://index.novus/ca (~/.novus/ca/download)
+var CADownloadDir string
+
 func resolveSSLCertDirs() {
 	SSLCertificatesDir = filepath.Join(NovusStateDir, "certs")
 
-	logger.Debugf("SSL paths resolved.\n\tSSLCertificatesDir = %s", SSLCertificatesDir)
+	// Same file names as mkcert uses, so an imported mkcert root can be used with mkcert as well
+	CADir = filepath.Join(NovusStateDir, "ca")
+	CACertFilePath = filepath.Join(CADir, "rootCA.pem")
+	CAKeyFilePath = filepath.Join(CADir, "rootCA-key.pem")
+	CADownloadDir = filepath.Join(CADir, "download")
+
+	logger.Debugf(
+		"SSL paths resolved.\n"+
+			"\tSSLCertificatesDir = %s\n"+
+			"\tCADir = %s",
+		SSLCertificatesDir,
+		CADir,
+	)
 }
@@ -26,6 +26,11 @@ func resolveSudoDirs() {
 		DNSResolverDir,
 		SudoersFilePath,
 	}
+	for _, includeFilePath := range []string{NginxIncludeFilePath, DNSMasqIncludeFilePath} {
+		if includeFilePath != "" {
+			SudoAllowedPaths = append(SudoAllowedPaths, includeFilePath)
+		}
+	}
 
 	logger.Debugf(
 		"Sudo paths resolved.\n"+
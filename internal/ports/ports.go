@@ -4,6 +4,7 @@ import (
 	"net"
 	"strings"
 
+	"github.com/jozefcipa/novus/internal/dry_run"
 	"github.com/jozefcipa/novus/internal/logger"
 	"github.com/jozefcipa/novus/internal/sudo"
 )
@@ -41,6 +42,12 @@ func parseLsof(lsofRecords []string) map[string]string {
 type PortUsage = map[string]string
 
 func CheckPortsUsage(ports ...string) PortUsage {
+	// Checking ports requires sudo, which we don't want to call in the dry-run mode
+	if dry_run.Enabled {
+		logger.Debugf("[dry-run] Skipping ports availability check")
+		return PortUsage{}
+	}
+
 	logger.Infof("Checking ports availability...")
 	lsof := lsof(ports)
 	logger.Debugf("lsof result:\n%s", strings.Join(lsof, "\n"))
@@ -0,0 +1,40 @@
+package process
+
+import (
+	"os"
+	"sync"
+)
+
+var failureHooks []func()
+var exiting bool
+var mu sync.Mutex
+
+// Registers a function that will be called before Novus exits with a non-zero exit code,
+// e.g. to roll back changes that have been applied only partially
+func OnFailure(hook func()) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	failureHooks = append(failureHooks, hook)
+}
+
+// Exit terminates the program with the given exit code.
+// If the code is non-zero, failure hooks are called first (in the reverse order of registration).
+func Exit(code int) {
+	mu.Lock()
+	// If a hook itself fails, exit immediately to avoid an infinite loop
+	if code == 0 || exiting {
+		mu.Unlock()
+		os.Exit(code)
+	}
+	exiting = true
+	hooks := failureHooks
+	failureHooks = nil
+	mu.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+
+	os.Exit(code)
+}
@@ -0,0 +1,122 @@
+package proxy_manager
+
+import (
+	"github.com/jozefcipa/novus/internal/config"
+	"github.com/jozefcipa/novus/internal/fs"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/nginx"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/ports"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/proxy_server"
+	"github.com/jozefcipa/novus/internal/request_log"
+	"github.com/jozefcipa/novus/internal/settings"
+	"github.com/jozefcipa/novus/internal/sharedtypes"
+)
+
+// Proxy manager routes the calls to the proxy selected in the settings,
+// Nginx (default) or the built-in Novus proxy (`proxy: builtin`)
+
+// HTTP, HTTPS
+var Ports = []string{proxy_server.HTTPPort, proxy_server.HTTPSPort}
+
+// Name of the proxy selected in the settings, shown to the user
+func ServerName() string {
+	if settings.UseBuiltinProxy() {
+		return "Novus proxy"
+	}
+	return "Nginx"
+}
+
+// Name of the process listening on the proxy ports (as reported by lsof)
+func processName() string {
+	if settings.UseBuiltinProxy() {
+		return "novus"
+	}
+	return "nginx"
+}
+
+func CheckPortsAvailability(portsUsage ports.PortUsage) {
+	for _, port := range Ports {
+		if portUsedBy, isUsed := portsUsage[port]; isUsed && portUsedBy != processName() {
+			logger.Errorf("Cannot start %s: Port %s is already used by '%s'", ServerName(), port, portUsedBy)
+			process.Exit(1)
+		}
+	}
+}
+
+// Configure stores the routes in the app state and generates the Nginx configuration for the app, returns true if it has changed.
+// The built-in proxy reads the routes from the state, so there's nothing else to configure.
+func Configure(appConfig config.NovusConfig, sslCerts sharedtypes.DomainCertificates, appState *novus.AppState) bool {
+	// Update routes in state
+	appState.Routes = appConfig.Routes
+
+	if settings.UseBuiltinProxy() {
+		// The proxy writes the request logs there
+		fs.MakeDirOrExit(paths.AppLogsDir(appConfig.AppName))
+		return false
+	}
+
+	return nginx.Configure(appConfig, sslCerts)
+}
+
+func RemoveConfiguration(appName string) {
+	if settings.UseBuiltinProxy() {
+		return
+	}
+
+	nginx.RemoveConfiguration(appName)
+}
+
+// Reload applies the configuration, the built-in proxy only needs to be running as it reloads the routes by itself
+func Reload() {
+	if settings.UseBuiltinProxy() {
+		proxy_server.EnsureRunning()
+		return
+	}
+
+	nginx.Reload()
+}
+
+func Restart() {
+	if settings.UseBuiltinProxy() {
+		proxy_server.Restart()
+		return
+	}
+
+	nginx.Restart()
+}
+
+func Stop() {
+	if settings.UseBuiltinProxy() {
+		proxy_server.Stop()
+		return
+	}
+
+	nginx.Stop()
+}
+
+func IsRunning() bool {
+	if settings.UseBuiltinProxy() {
+		return proxy_server.IsRunning()
+	}
+
+	return nginx.IsRunning()
+}
+
+// RotateLogs rotates request logs that exceeded the maximum size and makes the proxy write to the new files
+func RotateLogs() {
+	if request_log.RotateAll() {
+		ReopenLogs()
+	}
+}
+
+// ReopenLogs is needed after the request logs are rotated, the built-in proxy opens the log files for every write
+func ReopenLogs() {
+	if settings.UseBuiltinProxy() {
+		return
+	}
+
+	nginx.ReopenLogs()
+}
@@ -0,0 +1,49 @@
+package proxy_manager
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jozefcipa/novus/internal/config"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/settings"
+	"github.com/jozefcipa/novus/internal/sharedtypes"
+)
+
+func TestConfigureBuiltinProxyStoresRoutes(t *testing.T) {
+	stateDir := t.TempDir()
+	paths.NovusSettingsFilePath = filepath.Join(stateDir, "settings.yml")
+	paths.NovusLogsDir = filepath.Join(stateDir, "logs")
+	if err := os.WriteFile(paths.NovusSettingsFilePath, []byte("proxy: builtin\n"), 0644); err != nil {
+		t.Fatalf("Failed to write settings: %v", err)
+	}
+	if !settings.UseBuiltinProxy() {
+		t.Fatalf("Expected the built-in proxy to be enabled")
+	}
+
+	// Same as `novus serve` for a new app
+	conf := config.NovusConfig{
+		AppName: "app",
+		Routes: []sharedtypes.Route{
+			{Domain: "api.test", Upstream: "http://localhost:4000"},
+			{Domain: "web.test", Upstream: "http://localhost:3000", Cors: true},
+		},
+	}
+	appState := &novus.AppState{Status: novus.APP_ACTIVE, Routes: []sharedtypes.Route{}}
+
+	if updated := Configure(conf, sharedtypes.DomainCertificates{}, appState); updated {
+		t.Errorf("Expected no proxy configuration to be updated")
+	}
+
+	if !reflect.DeepEqual(appState.Routes, conf.Routes) {
+		t.Errorf("App state routes = %v, want %v", appState.Routes, conf.Routes)
+	}
+
+	// The built-in proxy writes the request logs there
+	if _, err := os.Stat(paths.AppLogsDir("app")); err != nil {
+		t.Errorf("Expected the app logs directory to be created: %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package proxy_server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/request_log"
+)
+
+// Log files are opened for every write, so they can be rotated by the agent at any time
+var logMu sync.Mutex
+
+// Captures the response status and size for the access log
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *loggingResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *loggingResponseWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(data)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Needed for WebSockets (hijacking the connection) and flushing streamed responses
+func (w *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Details about the upstream response, filled in by the reverse proxy
+type upstreamInfo struct {
+	address  string
+	status   int
+	duration time.Duration
+}
+
+// Writes the request in the same format as the Nginx `novus_json` log format (see assets/nginx/log-format.template.conf)
+func writeAccessLog(app string, r *http.Request, w *loggingResponseWriter, upstream *upstreamInfo, startedAt time.Time) {
+	entry := request_log.Entry{
+		Time:       startedAt,
+		Host:       r.Host,
+		Method:     r.Method,
+		URI:        r.RequestURI,
+		Status:     w.status,
+		Bytes:      w.bytes,
+		Duration:   time.Since(startedAt).Seconds(),
+		Upstream:   upstream.address,
+		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+		Referer:    r.Referer(),
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		entry.RemoteAddr = host
+	}
+	if upstream.status != 0 {
+		entry.UpstreamStatus = fmt.Sprint(upstream.status)
+		entry.UpstreamDuration = fmt.Sprintf("%.3f", upstream.duration.Seconds())
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	appendLine(paths.AppAccessLogFilePath(app), string(line))
+}
+
+// Writes the upstream error similarly to the Nginx error log
+func writeErrorLog(app string, r *http.Request, upstream string, err error) {
+	line := fmt.Sprintf(
+		"%s [error] %v, client: %s, server: %s, request: \"%s %s %s\", upstream: \"%s\", host: \"%s\"",
+		time.Now().Format("2006/01/02 15:04:05"), err, r.RemoteAddr, requestHost(r), r.Method, r.RequestURI, r.Proto, upstream, r.Host,
+	)
+	appendLine(paths.AppErrorLogFilePath(app), line)
+}
+
+func appendLine(path string, line string) {
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		logger.Debugf("Failed to open log file %s: %v", path, err)
+		return
+	}
+	defer file.Close()
+
+	file.WriteString(line + "\n")
+}
@@ -0,0 +1,78 @@
+package proxy_server
+
+import (
+	"syscall"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/daemon"
+	"github.com/jozefcipa/novus/internal/dry_run"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/transaction"
+)
+
+const daemonName = "proxy"
+
+// Restart (re)starts the proxy background process
+func Restart() {
+	if dry_run.Enabled {
+		dry_run.Record(dry_run.Action{Type: dry_run.RestartService, Target: "novus-proxy"})
+		return
+	}
+
+	loader := logger.Loadingf("Restarting Novus proxy")
+	stop()
+
+	if err := daemon.Start(daemonName, "proxy", "run"); err != nil {
+		loader.Errorf("Failed to start Novus proxy.")
+		logger.Errorf(err.Error())
+		logger.Hintf("See %s for more info.", LogFilePath())
+		process.Exit(1)
+	}
+
+	loader.Checkf("Novus proxy restarted")
+}
+
+// EnsureRunning starts the proxy if it's not running.
+// Routes are reloaded from the state automatically, so a running proxy doesn't need to be restarted.
+func EnsureRunning() {
+	if dry_run.Enabled {
+		dry_run.Record(dry_run.Action{Type: dry_run.ReloadService, Target: "novus-proxy"})
+		return
+	}
+
+	// Make sure the proxy is running again if anything fails later on
+	transaction.OnRollback("novus-proxy", EnsureRunning)
+
+	if !IsRunning() {
+		Restart()
+	}
+}
+
+func Stop() {
+	loader := logger.Loadingf("Stopping Novus proxy")
+	stop()
+	loader.Infof("🚫 Novus proxy stopped")
+}
+
+func IsRunning() bool {
+	_, running := daemon.IsRunning(daemonName)
+	return running
+}
+
+func LogFilePath() string {
+	return daemon.LogFilePath(daemonName)
+}
+
+// Stops the process and waits until it exits, so the ports are released
+func stop() {
+	pid, running := daemon.IsRunning(daemonName)
+	if !running || !daemon.Stop(daemonName) {
+		return
+	}
+
+	// Signal 0 only checks whether the process still exists
+	for i := 0; i < 50 && syscall.Kill(pid, syscall.Signal(0)) == nil; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,261 @@
+package proxy_server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/ca"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/paths"
+)
+
+// Built-in alternative to Nginx. It terminates TLS with the certificates created by Novus,
+// proxies the requests (including WebSockets) to the upstreams and serves the Novus internal pages.
+// It's enabled by `proxy: builtin` in ~/.novus/settings.yml and runs as a Novus background process.
+
+const HTTPPort = "80"
+const HTTPSPort = "443"
+
+type proxyServer struct {
+	routes *routingTable
+	// Proxy for the Novus agent (control API and metrics)
+	agentProxy *httputil.ReverseProxy
+}
+
+// Serve runs the HTTP and HTTPS servers until the process is terminated
+func Serve() error {
+	proxy := &proxyServer{
+		routes:     newRoutingTable(),
+		agentProxy: httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "http", Host: novus.NovusAgentAddress}),
+	}
+	proxy.routes.refresh()
+
+	httpServer := &http.Server{
+		Addr:              ":" + HTTPPort,
+		Handler:           http.HandlerFunc(proxy.serveHTTP),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	httpsServer := &http.Server{
+		Addr:              ":" + HTTPSPort,
+		Handler:           http.HandlerFunc(proxy.serveHTTPS),
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig:         &tls.Config{GetCertificate: proxy.routes.getCertificate},
+		// Handshake errors for unknown domains are expected, don't spam the logs
+		ErrorLog: log.New(debugLogWriter{}, "", 0),
+	}
+
+	// Shut down gracefully on termination
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(ctx)
+		httpsServer.Shutdown(ctx)
+	}()
+
+	errs := make(chan error, 2)
+	go func() {
+		errs <- httpServer.ListenAndServe()
+	}()
+	go func() {
+		errs <- httpsServer.ListenAndServeTLS("", "")
+	}()
+
+	logger.Infof("Novus proxy listening on :%s and :%s", HTTPPort, HTTPSPort)
+	if err := <-errs; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// Plain HTTP is only redirected to HTTPS
+func (p *proxyServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	p.routes.refresh()
+
+	host := requestHost(r)
+	// The CA is also available over plain HTTP on any address (e.g. http://<LAN IP>/ca),
+	// so other devices can download it before they trust the Novus certificates
+	if !p.routes.isKnownHost(host) && isCADownload(r) {
+		p.serveCADownload(w, r)
+		return
+	}
+	if !p.routes.isKnownHost(host) {
+		p.serveErrorPage(w, http.StatusNotFound)
+		return
+	}
+
+	http.Redirect(w, r, "https://"+host+r.RequestURI, http.StatusMovedPermanently)
+}
+
+func (p *proxyServer) serveHTTPS(w http.ResponseWriter, r *http.Request) {
+	p.routes.refresh()
+
+	switch host := requestHost(r); host {
+	case novus.NovusInternalDomain:
+		p.serveInternal(w, r)
+	case novus.NovusIndexDomain:
+		p.serveIndex(w, r)
+	default:
+		route, ok := p.routes.lookup(host)
+		if !ok {
+			p.serveErrorPage(w, http.StatusNotFound)
+			return
+		}
+		p.serveRoute(w, r, route)
+	}
+}
+
+func (p *proxyServer) serveRoute(w http.ResponseWriter, r *http.Request, route route) {
+	startedAt := time.Now()
+	upstream := &upstreamInfo{address: route.upstream.Host}
+	logWriter := &loggingResponseWriter{ResponseWriter: w}
+
+	if route.cors {
+		setCORSHeaders(w.Header(), r)
+	}
+
+	reverseProxy := &httputil.ReverseProxy{
+		Rewrite: func(proxyRequest *httputil.ProxyRequest) {
+			// Same as Nginx `proxy_pass`, the Host header is set to the upstream host
+			proxyRequest.SetURL(route.upstream)
+		},
+		// Stream the responses (Nginx `proxy_buffering off`)
+		FlushInterval: -1,
+		ModifyResponse: func(response *http.Response) error {
+			upstream.status = response.StatusCode
+			upstream.duration = time.Since(startedAt)
+			return nil
+		},
+		// The handler gets the rewritten request, the original one is logged instead
+		ErrorHandler: func(w http.ResponseWriter, _ *http.Request, err error) {
+			writeErrorLog(route.app, r, route.upstream.String(), err)
+			p.serveErrorPage(w, http.StatusBadGateway)
+		},
+	}
+	reverseProxy.ServeHTTP(logWriter, r)
+
+	writeAccessLog(route.app, r, logWriter, upstream, startedAt)
+}
+
+// internal.novus serves the assets of the error pages, the state for the index page and proxies the agent API
+func (p *proxyServer) serveInternal(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/images/"):
+		http.ServeFile(w, r, filepath.Join(paths.AssetsDir, "nginx", filepath.Clean(r.URL.Path)))
+	case strings.HasPrefix(r.URL.Path, "/api/"), r.URL.Path == "/metrics":
+		r.Header.Set("X-Forwarded-Proto", "https")
+		p.agentProxy.ServeHTTP(w, r)
+	case r.URL.Path == "/state.json":
+		w.Header().Set("Cache-Control", "no-store, no-cache")
+		w.Header().Set("Access-Control-Allow-Origin", "https://"+novus.NovusIndexDomain)
+		w.Header().Set("Content-Type", "application/json")
+		content, err := os.ReadFile(paths.NovusStateFilePath)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write(content)
+	default:
+		http.Redirect(w, r, "https://"+novus.NovusIndexDomain, http.StatusMovedPermanently)
+	}
+}
+
+// index.novus shows the routing table
+func (p *proxyServer) serveIndex(w http.ResponseWriter, r *http.Request) {
+	if isCADownload(r) {
+		p.serveCADownload(w, r)
+		return
+	}
+	if r.URL.Path != "/" {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	http.ServeFile(w, r, filepath.Join(paths.AssetsDir, "nginx/html/index.html"))
+}
+
+func isCADownload(r *http.Request) bool {
+	return r.URL.Path == "/ca" || strings.HasPrefix(r.URL.Path, "/ca/")
+}
+
+// Download page of the Novus CA (see `novus ca export`)
+func (p *proxyServer) serveCADownload(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/ca" {
+		http.ServeFile(w, r, filepath.Join(paths.AssetsDir, "nginx/html/ca.html"))
+		return
+	}
+
+	fileName := filepath.Base(r.URL.Path)
+	for _, format := range ca.ExportFormats {
+		if ca.DownloadFileName(format) == fileName {
+			w.Header().Set("Content-Type", caContentTypes[format])
+			http.ServeFile(w, r, filepath.Join(paths.CADownloadDir, fileName))
+			return
+		}
+	}
+
+	p.serveErrorPage(w, http.StatusNotFound)
+}
+
+// Same as the types in the Nginx config
+var caContentTypes = map[ca.ExportFormat]string{
+	ca.EXPORT_PEM:          "application/x-pem-file",
+	ca.EXPORT_DER:          "application/x-x509-ca-cert",
+	ca.EXPORT_MOBILECONFIG: "application/x-apple-aspen-config",
+}
+
+// Serves 404.html or 502.html from the assets
+func (p *proxyServer) serveErrorPage(w http.ResponseWriter, status int) {
+	content, err := os.ReadFile(filepath.Join(paths.AssetsDir, "nginx/html", strconv.Itoa(status)+".html"))
+	if err != nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(content)
+}
+
+// Same headers as the Nginx CORS snippet
+func setCORSHeaders(header http.Header, r *http.Request) {
+	header.Add("Access-Control-Allow-Origin", "*")
+	header.Add("Access-Control-Allow-Methods", "GET, POST, OPTIONS, PUT, DELETE, PATCH")
+	header.Add("Access-Control-Allow-Headers", r.Header.Get("Access-Control-Request-Headers"))
+	header.Add("Access-Control-Allow-Private-Network", "true")
+}
+
+// Redirects the HTTP server errors to debug logs
+type debugLogWriter struct{}
+
+func (debugLogWriter) Write(p []byte) (int, error) {
+	logger.Debugf("%s", strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+// Host without the port, lowercased
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
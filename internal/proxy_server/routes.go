@@ -0,0 +1,168 @@
+package proxy_server
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/sharedtypes"
+)
+
+type route struct {
+	app      string
+	upstream *url.URL
+	cors     bool
+}
+
+// Routing table is reloaded whenever the state file changes, so there is no need to restart the proxy
+type routingTable struct {
+	mu sync.RWMutex
+	// Routes of the active apps by domain
+	routes map[string]route
+	// SSL certificates of all domains, including the internal ones
+	certificates map[string]sharedtypes.Certificate
+	// Modification time of the loaded state file
+	loadedAt time.Time
+
+	// Parsed certificates, reloaded if the certificate file changes (e.g. renewed)
+	certCacheMu sync.Mutex
+	certCache   map[string]cachedCertificate
+}
+
+type cachedCertificate struct {
+	modTime     time.Time
+	certificate *tls.Certificate
+}
+
+func newRoutingTable() *routingTable {
+	return &routingTable{
+		routes:       map[string]route{},
+		certificates: map[string]sharedtypes.Certificate{},
+		certCache:    map[string]cachedCertificate{},
+	}
+}
+
+// Reloads the routes if the state file has been modified since the last load.
+// The state is read directly (not via novus.GetState()), so a broken state file never stops the proxy.
+func (t *routingTable) refresh() {
+	info, err := os.Stat(paths.NovusStateFilePath)
+	if err != nil {
+		return
+	}
+
+	t.mu.RLock()
+	upToDate := info.ModTime().Equal(t.loadedAt)
+	t.mu.RUnlock()
+	if upToDate {
+		return
+	}
+
+	content, err := os.ReadFile(paths.NovusStateFilePath)
+	if err != nil {
+		logger.Warnf("Failed to read state file: %v", err)
+		return
+	}
+
+	var state novus.NovusState
+	if err := json.Unmarshal(content, &state); err != nil {
+		logger.Warnf("Failed to parse state file, keeping the previous routes: %v", err)
+		return
+	}
+
+	routes := map[string]route{}
+	certificates := map[string]sharedtypes.Certificate{}
+	for appName, appState := range state.Apps {
+		for domain, certificate := range appState.SSLCertificates {
+			certificates[domain] = certificate
+		}
+
+		// Internal domains are handled by the proxy itself
+		if appName == novus.NovusInternalAppName || appState.Status != novus.APP_ACTIVE {
+			continue
+		}
+
+		for _, r := range appState.Routes {
+			upstream, err := url.Parse(r.Upstream)
+			if err != nil {
+				logger.Warnf("Skipping route %s, invalid upstream %s: %v", r.Domain, r.Upstream, err)
+				continue
+			}
+			routes[strings.ToLower(r.Domain)] = route{app: appName, upstream: upstream, cors: r.Cors}
+		}
+	}
+
+	t.mu.Lock()
+	t.routes = routes
+	t.certificates = certificates
+	t.loadedAt = info.ModTime()
+	t.mu.Unlock()
+
+	logger.Debugf("Routes reloaded [%d routes, %d certificates]", len(routes), len(certificates))
+}
+
+func (t *routingTable) lookup(host string) (route, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	r, ok := t.routes[host]
+	return r, ok
+}
+
+// Whether Novus serves the domain (either a route or an internal domain)
+func (t *routingTable) isKnownHost(host string) bool {
+	if host == novus.NovusInternalDomain || host == novus.NovusIndexDomain {
+		return true
+	}
+
+	_, ok := t.lookup(host)
+	return ok
+}
+
+// Returns the certificate for the domain requested via SNI
+func (t *routingTable) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	t.refresh()
+
+	domain := strings.ToLower(hello.ServerName)
+	t.mu.RLock()
+	certificate, ok := t.certificates[domain]
+	t.mu.RUnlock()
+	if !ok {
+		return nil, &unknownDomainError{domain: domain}
+	}
+
+	info, err := os.Stat(certificate.CertFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	t.certCacheMu.Lock()
+	defer t.certCacheMu.Unlock()
+
+	if cached, ok := t.certCache[domain]; ok && cached.modTime.Equal(info.ModTime()) {
+		return cached.certificate, nil
+	}
+
+	loaded, err := tls.LoadX509KeyPair(certificate.CertFilePath, certificate.KeyFilePath)
+	if err != nil {
+		return nil, err
+	}
+	t.certCache[domain] = cachedCertificate{modTime: info.ModTime(), certificate: &loaded}
+	logger.Debugf("Certificate loaded [%s]", domain)
+
+	return &loaded, nil
+}
+
+type unknownDomainError struct {
+	domain string
+}
+
+func (e *unknownDomainError) Error() string {
+	return "no certificate for domain \"" + e.domain + "\""
+}
@@ -0,0 +1,204 @@
+package replay
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/har"
+	"github.com/jozefcipa/novus/internal/request_log"
+)
+
+// Request is a recorded request that can be sent again
+type Request struct {
+	Method        string
+	URL           string
+	Headers       []har.NameValue
+	Body          []byte
+	BodyTruncated bool
+
+	// Response received when the request was recorded
+	OriginalStatus   int
+	OriginalDuration time.Duration
+}
+
+type Result struct {
+	Request  Request
+	URL      string
+	Status   int
+	Duration time.Duration
+	Err      error
+}
+
+func (r Result) StatusChanged() bool {
+	return r.Err != nil || r.Status != r.Request.OriginalStatus
+}
+
+// These headers are set by the HTTP client itself
+var skippedHeaders = []string{"host", "content-length", "connection", "keep-alive", "transfer-encoding", "upgrade", "proxy-connection", "te", "trailer"}
+
+// LoadFile reads requests from a HAR file (e.g. created by `novus capture`) or a Novus access log
+func LoadFile(path string) ([]Request, error) {
+	if harFile, err := har.ReadFile(path); err == nil && harFile.Log.Version != "" {
+		return fromHAR(harFile)
+	}
+
+	lines := request_log.ReadLines(path)
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("file is empty or does not exist")
+	}
+
+	requests := []Request{}
+	for i, line := range lines {
+		entry, ok := request_log.ParseLine(line)
+		if !ok {
+			return nil, fmt.Errorf("line %d is neither a HAR file nor a Novus access log entry", i+1)
+		}
+
+		// Access logs don't contain headers and bodies, so only the method and URL can be replayed
+		requests = append(requests, Request{
+			Method:           entry.Method,
+			URL:              fmt.Sprintf("https://%s%s", entry.Host, entry.URI),
+			Headers:          []har.NameValue{},
+			OriginalStatus:   entry.Status,
+			OriginalDuration: time.Duration(entry.Duration * float64(time.Second)),
+		})
+	}
+
+	return requests, nil
+}
+
+func fromHAR(harFile har.HAR) ([]Request, error) {
+	requests := []Request{}
+	for i, entry := range harFile.Log.Entries {
+		request := Request{
+			Method:           entry.Request.Method,
+			URL:              entry.Request.URL,
+			Headers:          entry.Request.Headers,
+			OriginalStatus:   entry.Response.Status,
+			OriginalDuration: time.Duration(entry.Time * float64(time.Millisecond)),
+		}
+
+		if postData := entry.Request.PostData; postData != nil {
+			body, err := har.DecodeText(postData.Text, postData.Encoding)
+			if err != nil {
+				return nil, fmt.Errorf("entry %d: invalid request body: %v", i+1, err)
+			}
+			request.Body = body
+			request.BodyTruncated = entry.Request.BodySize > int64(len(body))
+		}
+
+		requests = append(requests, request)
+	}
+
+	return requests, nil
+}
+
+// Send replays the request, if `targetDomain` is set, the request is sent to that domain instead of the original one
+func Send(client *http.Client, request Request, targetDomain string) Result {
+	result := Result{Request: request}
+
+	requestURL, err := url.Parse(request.URL)
+	if err != nil {
+		result.Err = fmt.Errorf("invalid URL: %v", err)
+		return result
+	}
+	if targetDomain != "" {
+		requestURL.Host = targetDomain
+	}
+	result.URL = requestURL.String()
+
+	var body io.Reader = http.NoBody
+	if len(request.Body) > 0 {
+		body = bytes.NewReader(request.Body)
+	}
+
+	req, err := http.NewRequest(request.Method, result.URL, body)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	for _, header := range request.Headers {
+		name := strings.ToLower(header.Name)
+		if strings.HasPrefix(name, ":") || isSkippedHeader(name) {
+			continue
+		}
+		req.Header.Add(header.Name, header.Value)
+	}
+
+	startedAt := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	result.Status = resp.StatusCode
+	result.Duration = time.Since(startedAt)
+
+	return result
+}
+
+func NewClient(insecure bool, timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		// Redirects are part of the recorded traffic, so they are not followed
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// MarshalResults is used for the --json output
+func MarshalResults(results []Result) ([]byte, error) {
+	type jsonResult struct {
+		Method           string  `json:"method"`
+		URL              string  `json:"url"`
+		OriginalStatus   int     `json:"originalStatus"`
+		Status           int     `json:"status"`
+		OriginalDuration float64 `json:"originalDuration"` // in milliseconds
+		Duration         float64 `json:"duration"`         // in milliseconds
+		Error            string  `json:"error,omitempty"`
+	}
+
+	out := []jsonResult{}
+	for _, result := range results {
+		item := jsonResult{
+			Method:           result.Request.Method,
+			URL:              result.URL,
+			OriginalStatus:   result.Request.OriginalStatus,
+			Status:           result.Status,
+			OriginalDuration: float64(result.Request.OriginalDuration.Microseconds()) / 1000,
+			Duration:         float64(result.Duration.Microseconds()) / 1000,
+		}
+		if result.Err != nil {
+			item.Error = result.Err.Error()
+		}
+		out = append(out, item)
+	}
+
+	return json.MarshalIndent(out, "", "  ")
+}
+
+func isSkippedHeader(name string) bool {
+	for _, skipped := range skippedHeaders {
+		if name == skipped {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package request_log
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter restricts which log entries are shown
+type Filter struct {
+	Domains  []string
+	Statuses []StatusMatcher
+	Since    time.Time
+}
+
+// StatusMatcher matches either an exact status code (e.g. 404) or a whole class (e.g. 5xx)
+type StatusMatcher struct {
+	Code  int
+	Class int
+}
+
+func (m StatusMatcher) Matches(status int) bool {
+	if m.Class != 0 {
+		return status/100 == m.Class
+	}
+	return status == m.Code
+}
+
+// ParseStatusFilter parses a comma separated list of status codes or classes, e.g. "5xx,404"
+func ParseStatusFilter(value string) ([]StatusMatcher, error) {
+	matchers := []StatusMatcher{}
+	if strings.TrimSpace(value) == "" {
+		return matchers, nil
+	}
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+
+		if len(part) == 3 && strings.HasSuffix(part, "xx") {
+			class, err := strconv.Atoi(part[:1])
+			if err != nil || class < 1 || class > 5 {
+				return nil, fmt.Errorf("invalid status class \"%s\"", part)
+			}
+			matchers = append(matchers, StatusMatcher{Class: class})
+			continue
+		}
+
+		code, err := strconv.Atoi(part)
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid status code \"%s\"", part)
+		}
+		matchers = append(matchers, StatusMatcher{Code: code})
+	}
+
+	return matchers, nil
+}
+
+// ParseSince parses a duration (e.g. 10m, 2h) relative to now
+func ParseSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return time.Time{}, fmt.Errorf("invalid duration \"%s\" (use e.g. 30s, 10m or 2h)", value)
+	}
+
+	return time.Now().Add(-duration), nil
+}
+
+func (f Filter) Matches(entry Entry) bool {
+	if len(f.Domains) > 0 && !slices.Contains(f.Domains, entry.Host) {
+		return false
+	}
+
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+
+	if len(f.Statuses) > 0 {
+		for _, matcher := range f.Statuses {
+			if matcher.Matches(entry.Status) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
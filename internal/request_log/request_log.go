@@ -0,0 +1,165 @@
+package request_log
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/paths"
+)
+
+const followPollInterval = 500 * time.Millisecond
+
+// Entry represents a single request logged by Nginx in the `novus_json` format
+// (see assets/nginx/log-format.template.conf)
+type Entry struct {
+	Time             time.Time `json:"time"`
+	Host             string    `json:"host"`
+	Method           string    `json:"method"`
+	URI              string    `json:"uri"`
+	Status           int       `json:"status"`
+	Bytes            int64     `json:"bytes"`
+	Duration         float64   `json:"duration"` // in seconds
+	Upstream         string    `json:"upstream"`
+	UpstreamStatus   string    `json:"upstreamStatus"`
+	UpstreamDuration string    `json:"upstreamDuration"`
+	RemoteAddr       string    `json:"remoteAddr"`
+	UserAgent        string    `json:"userAgent"`
+	Referer          string    `json:"referer"`
+}
+
+func ParseLine(line string) (Entry, bool) {
+	var entry Entry
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		return Entry{}, false
+	}
+
+	return entry, true
+}
+
+// Tail returns entries logged at the end of the file (reads at most `maxBytes` bytes)
+func Tail(path string, maxBytes int64) []Entry {
+	file, err := os.Open(path)
+	if err != nil {
+		logger.Debugf("Failed to open request log [%s]: %v", path, err)
+		return []Entry{}
+	}
+	defer file.Close()
+
+	offset := int64(0)
+	if info, err := file.Stat(); err == nil && info.Size() > maxBytes {
+		offset = info.Size() - maxBytes
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return []Entry{}
+	}
+
+	entries := []Entry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		// The first line might be incomplete if we started reading in the middle of the file
+		if entry, ok := ParseLine(strings.TrimSpace(scanner.Text())); ok {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// ReadEntries returns all entries logged for the app, including the most recently rotated file
+func ReadEntries(appName string) []Entry {
+	entries := []Entry{}
+	for _, line := range ReadLines(paths.AppAccessLogFilePath(appName)) {
+		if entry, ok := ParseLine(line); ok {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// ReadLines returns all lines of the log file, prepended by the lines of the most recently rotated file
+func ReadLines(path string) []string {
+	lines := []string{}
+	for _, filePath := range []string{rotatedFileName(path, 1), path} {
+		file, err := os.Open(filePath)
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		file.Close()
+	}
+
+	return lines
+}
+
+// FollowLines watches the log file and calls `onLine` for every new line until `stop` is closed.
+// It starts at the end of the file and handles the file being rotated or truncated.
+func FollowLines(path string, stop <-chan struct{}, onLine func(line string)) {
+	offset := int64(0)
+	if info, err := os.Stat(path); err == nil {
+		offset = info.Size()
+	}
+
+	ticker := time.NewTicker(followPollInterval)
+	defer ticker.Stop()
+
+	partial := ""
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		// The file has been rotated or truncated, start from the beginning
+		if info.Size() < offset {
+			offset = 0
+			partial = ""
+		}
+		if info.Size() == offset {
+			continue
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			file.Close()
+			continue
+		}
+		data, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			continue
+		}
+		offset += int64(len(data))
+
+		// Only complete lines are emitted, the rest is kept until Nginx finishes writing it
+		chunk := partial + string(data)
+		lines := strings.Split(chunk, "\n")
+		partial = lines[len(lines)-1]
+		for _, line := range lines[:len(lines)-1] {
+			if line = strings.TrimSpace(line); line != "" {
+				onLine(line)
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package request_log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/paths"
+)
+
+// Log files bigger than this are rotated
+const MaxLogFileSize = 10 * 1024 * 1024 // 10 MB
+
+// How many rotated files are kept (access.log.1 ... access.log.3)
+const MaxRotatedFiles = 3
+
+// RotateAll rotates access and error logs of all apps that exceeded the maximum size.
+// Returns true if any file has been rotated, in which case Nginx must reopen its log files.
+func RotateAll() bool {
+	appDirs, err := os.ReadDir(paths.NovusLogsDir)
+	if err != nil {
+		logger.Debugf("Failed to read logs directory [%s]: %v", paths.NovusLogsDir, err)
+		return false
+	}
+
+	rotated := false
+	for _, appDir := range appDirs {
+		if !appDir.IsDir() {
+			continue
+		}
+
+		for _, path := range []string{paths.AppAccessLogFilePath(appDir.Name()), paths.AppErrorLogFilePath(appDir.Name())} {
+			if rotateFile(path) {
+				rotated = true
+			}
+		}
+	}
+
+	return rotated
+}
+
+func rotateFile(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() < MaxLogFileSize {
+		return false
+	}
+
+	logger.Debugf("Rotating log file [%s]", path)
+
+	// Shift the older files: access.log.2 -> access.log.3, access.log.1 -> access.log.2, ...
+	os.Remove(rotatedFileName(path, MaxRotatedFiles))
+	for i := MaxRotatedFiles - 1; i >= 1; i-- {
+		os.Rename(rotatedFileName(path, i), rotatedFileName(path, i+1))
+	}
+
+	if err := os.Rename(path, rotatedFileName(path, 1)); err != nil {
+		logger.Warnf("Failed to rotate log file %s: %v", filepath.Base(path), err)
+		return false
+	}
+
+	return true
+}
+
+func rotatedFileName(path string, index int) string {
+	return fmt.Sprintf("%s.%d", path, index)
+}
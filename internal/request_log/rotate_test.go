@@ -0,0 +1,104 @@
+package request_log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Creates a (sparse) file of the given size with a marker at the beginning, so rotated files can be told apart
+func writeLogFile(t *testing.T, path string, marker string, size int64) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(marker), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+	if err := os.Truncate(path, max(size, int64(len(marker)))); err != nil {
+		t.Fatalf("Failed to resize %s: %v", path, err)
+	}
+}
+
+func readMarker(t *testing.T, path string, length int) string {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	// Sparse files are padded with zeros
+	return strings.TrimRight(string(content[:min(length, len(content))]), "\x00")
+}
+
+func TestRotateFile(t *testing.T) {
+	tests := []struct {
+		name         string
+		size         int64
+		rotatedFiles []string
+		wantRotated  bool
+		// Expected markers of access.log, access.log.1, access.log.2 and access.log.3 ("" if the file doesn't exist)
+		wantFiles []string
+	}{
+		{
+			name:        "below the threshold",
+			size:        MaxLogFileSize - 1,
+			wantRotated: false,
+			wantFiles:   []string{"new", "", "", ""},
+		},
+		{
+			name:        "at the threshold",
+			size:        MaxLogFileSize,
+			wantRotated: true,
+			wantFiles:   []string{"", "new", "", ""},
+		},
+		{
+			name:         "shifts rotated files",
+			size:         MaxLogFileSize + 1,
+			rotatedFiles: []string{"old1", "old2"},
+			wantRotated:  true,
+			wantFiles:    []string{"", "new", "old1", "old2"},
+		},
+		{
+			name:         "drops the oldest file",
+			size:         MaxLogFileSize,
+			rotatedFiles: []string{"old1", "old2", "old3"},
+			wantRotated:  true,
+			wantFiles:    []string{"", "new", "old1", "old2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "access.log")
+			writeLogFile(t, path, "new", tt.size)
+			for i, marker := range tt.rotatedFiles {
+				writeLogFile(t, rotatedFileName(path, i+1), marker, 0)
+			}
+
+			if rotated := rotateFile(path); rotated != tt.wantRotated {
+				t.Errorf("rotateFile() = %t, want %t", rotated, tt.wantRotated)
+			}
+
+			for i, want := range tt.wantFiles {
+				filePath := path
+				if i > 0 {
+					filePath = rotatedFileName(path, i)
+				}
+				if marker := readMarker(t, filePath, 4); marker != want {
+					t.Errorf("%s contains %q, want %q", filepath.Base(filePath), marker, want)
+				}
+			}
+
+			if _, err := os.Stat(rotatedFileName(path, MaxRotatedFiles+1)); err == nil {
+				t.Errorf("Expected at most %d rotated files", MaxRotatedFiles)
+			}
+		})
+	}
+}
+
+func TestRotateFileMissing(t *testing.T) {
+	if rotateFile(filepath.Join(t.TempDir(), "access.log")) {
+		t.Errorf("Expected a missing log file not to be rotated")
+	}
+}
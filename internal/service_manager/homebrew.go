@@ -0,0 +1,35 @@
+package service_manager
+
+import (
+	"fmt"
+
+	"github.com/jozefcipa/novus/internal/homebrew"
+)
+
+// Services installed via Homebrew, controlled by `brew services`
+type homebrewServices struct{}
+
+func (h *homebrewServices) Name() string {
+	return "homebrew"
+}
+
+func (h *homebrewServices) Restart(svc string) {
+	homebrew.RestartService(svc)
+}
+
+func (h *homebrewServices) Stop(svc string) {
+	homebrew.StopService(svc)
+}
+
+func (h *homebrewServices) IsRunning(svc string) bool {
+	return homebrew.IsServiceRunning(svc)
+}
+
+// Homebrew services run as the current user
+func (h *homebrewServices) RequiresRoot(svc string) bool {
+	return false
+}
+
+func (h *homebrewServices) InfoCommand(svc string) string {
+	return fmt.Sprintf("brew services info %s --json", svc)
+}
@@ -0,0 +1,60 @@
+package service_manager
+
+import (
+	"runtime"
+
+	"github.com/jozefcipa/novus/internal/logger"
+)
+
+// ServiceManager starts and stops the services Novus depends on (Nginx, DNSMasq)
+type ServiceManager interface {
+	// Name of the service manager shown in logs, e.g. "homebrew"
+	Name() string
+	Restart(svc string)
+	Stop(svc string)
+	IsRunning(svc string) bool
+	// Whether the service runs as root, so its binary must be run via sudo as well (e.g. `nginx -t`)
+	RequiresRoot(svc string) bool
+	// Command that shows more details about the service, used in hints if the service fails to start
+	InfoCommand(svc string) string
+}
+
+var current ServiceManager
+
+// Get returns the service manager of the current platform,
+// Homebrew services on macOS and systemd on Linux
+func Get() ServiceManager {
+	if current != nil {
+		return current
+	}
+
+	switch runtime.GOOS {
+	case "linux":
+		current = &systemd{userUnits: map[string]bool{}}
+	default:
+		current = &homebrewServices{}
+	}
+	logger.Debugf("Using %s service manager", current.Name())
+
+	return current
+}
+
+func Restart(svc string) {
+	Get().Restart(svc)
+}
+
+func Stop(svc string) {
+	Get().Stop(svc)
+}
+
+func IsRunning(svc string) bool {
+	return Get().IsRunning(svc)
+}
+
+func RequiresRoot(svc string) bool {
+	return Get().RequiresRoot(svc)
+}
+
+func InfoCommand(svc string) string {
+	return Get().InfoCommand(svc)
+}
@@ -0,0 +1,88 @@
+package service_manager
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/process"
+)
+
+// Services controlled by systemd.
+// If the user has defined a user unit for the service (~/.config/systemd/user), it's preferred,
+// otherwise the system unit installed by the package manager is used (requires sudo).
+type systemd struct {
+	// Cache of the detected unit scope for each service
+	userUnits map[string]bool
+}
+
+func (s *systemd) Name() string {
+	return "systemd"
+}
+
+func (s *systemd) Restart(svc string) {
+	s.execSystemctl(svc, "restart")
+}
+
+func (s *systemd) Stop(svc string) {
+	s.execSystemctl(svc, "stop")
+}
+
+func (s *systemd) IsRunning(svc string) bool {
+	// `is-active` doesn't need root even for system units
+	commands := append(s.scopeArgs(svc), "is-active", "--quiet", unitName(svc))
+	logger.Debugf("Running \"systemctl %s\"", strings.Join(commands, " "))
+
+	isRunning := exec.Command("systemctl", commands...).Run() == nil
+	logger.Debugf("Service status of \"%s\" [running=%t]", svc, isRunning)
+
+	return isRunning
+}
+
+func (s *systemd) RequiresRoot(svc string) bool {
+	return !s.isUserUnit(svc)
+}
+
+func (s *systemd) InfoCommand(svc string) string {
+	return strings.Join(append(append([]string{"systemctl"}, s.scopeArgs(svc)...), "status", unitName(svc)), " ")
+}
+
+func (s *systemd) isUserUnit(svc string) bool {
+	if isUserUnit, ok := s.userUnits[svc]; ok {
+		return isUserUnit
+	}
+
+	// `systemctl cat` only succeeds if the unit file exists
+	isUserUnit := exec.Command("systemctl", "--user", "cat", unitName(svc)).Run() == nil
+	logger.Debugf("Systemd unit scope of \"%s\" [user=%t]", svc, isUserUnit)
+	s.userUnits[svc] = isUserUnit
+
+	return isUserUnit
+}
+
+func (s *systemd) scopeArgs(svc string) []string {
+	if s.isUserUnit(svc) {
+		return []string{"--user"}
+	}
+	return []string{}
+}
+
+func (s *systemd) execSystemctl(svc string, action string) {
+	commands := []string{"systemctl", "--user", action, unitName(svc)}
+	if !s.isUserUnit(svc) {
+		// System units can be only controlled by root
+		commands = []string{"sudo", "systemctl", action, unitName(svc)}
+	}
+
+	commandString := strings.Join(commands, " ")
+	logger.Debugf("Running \"%s\"", commandString)
+
+	if out, err := exec.Command(commands[0], commands[1:]...).CombinedOutput(); err != nil {
+		logger.Errorf("Failed to run \"%s\": %v\n%s", commandString, err, out)
+		process.Exit(1)
+	}
+}
+
+func unitName(svc string) string {
+	return svc + ".service"
+}
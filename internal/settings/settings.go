@@ -0,0 +1,126 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jozefcipa/novus/internal/fs"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/process"
+	"gopkg.in/yaml.v3"
+)
+
+type DNSServer string
+
+const (
+	DNS_DNSMASQ DNSServer = "dnsmasq"
+	DNS_BUILTIN DNSServer = "builtin"
+)
+
+type ProxyServer string
+
+const (
+	PROXY_NGINX   ProxyServer = "nginx"
+	PROXY_BUILTIN ProxyServer = "builtin"
+)
+
+type CertificateMode string
+
+const (
+	// One certificate for each domain
+	CERTS_PER_DOMAIN CertificateMode = "domain"
+	// One certificate with all domains of the app
+	CERTS_PER_APP CertificateMode = "app"
+)
+
+// Certificate authority used instead of the local Novus CA (e.g. an internal development CA of the company)
+type CASettings struct {
+	// PEM file with the CA certificate, it can contain the whole chain (intermediate first)
+	CertFile string `yaml:"certFile" validate:"required_with=KeyFile"`
+	// PEM file with the private key of the CA (PKCS #8, PKCS #1 or SEC 1)
+	KeyFile string `yaml:"keyFile" validate:"required_with=CertFile"`
+}
+
+// Settings are global user preferences stored in ~/.novus/settings.yml
+type Settings struct {
+	// Which DNS server answers the Novus domains
+	DNS DNSServer `yaml:"dns" validate:"oneof=dnsmasq builtin"`
+	// Which proxy routes the requests to the upstreams
+	Proxy ProxyServer `yaml:"proxy" validate:"oneof=nginx builtin"`
+	// Whether the SSL certificates are issued for each domain or for the whole app
+	Certificates CertificateMode `yaml:"certificates" validate:"oneof=domain app"`
+	// Custom certificate authority signing the SSL certificates
+	CA CASettings `yaml:"ca"`
+}
+
+var settings *Settings
+
+// Get returns the settings, missing values are filled with defaults
+func Get() *Settings {
+	if settings == nil {
+		settings = load()
+	}
+
+	return settings
+}
+
+func load() *Settings {
+	loaded := &Settings{
+		DNS:          DNS_DNSMASQ,
+		Proxy:        PROXY_NGINX,
+		Certificates: CERTS_PER_DOMAIN,
+	}
+
+	// The settings file is optional
+	if !fs.FileExists(paths.NovusSettingsFilePath) {
+		logger.Debugf("Settings file not found, using defaults [%s]", paths.NovusSettingsFilePath)
+		return loaded
+	}
+
+	logger.Debugf("Loading settings file [%s]", paths.NovusSettingsFilePath)
+	content := fs.ReadFileOrExit(paths.NovusSettingsFilePath)
+	if err := yaml.Unmarshal([]byte(content), loaded); err != nil {
+		logger.Errorf("Failed to parse the settings file %s: %v", paths.NovusSettingsFilePath, err)
+		process.Exit(1)
+	}
+
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	if err := validate.Struct(loaded); err != nil {
+		logger.Errorf("Settings file %s is invalid.\n\n%s", paths.NovusSettingsFilePath, err.(validator.ValidationErrors))
+		process.Exit(1)
+	}
+
+	loaded.CA.CertFile = expandHomeDir(loaded.CA.CertFile)
+	loaded.CA.KeyFile = expandHomeDir(loaded.CA.KeyFile)
+
+	return loaded
+}
+
+// Paths in the settings can start with ~/
+func expandHomeDir(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, path[2:])
+}
+
+func UseBuiltinDNS() bool {
+	return Get().DNS == DNS_BUILTIN
+}
+
+func UseBuiltinProxy() bool {
+	return Get().Proxy == PROXY_BUILTIN
+}
+
+func UseAppCertificates() bool {
+	return Get().Certificates == CERTS_PER_APP
+}
+
+func UseCustomCA() bool {
+	return Get().CA.CertFile != ""
+}
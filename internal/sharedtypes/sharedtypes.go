@@ -16,6 +16,8 @@ type Certificate struct {
 	CertFilePath string    `json:"certFilePath" validate:"required,filepath"`
 	KeyFilePath  string    `json:"keyFilePath" validate:"required,filepath"`
 	ExpiresAt    time.Time `json:"expiresAt" validate:"required"`
+	// Read from the certificate file, empty for certificates created before it was stored
+	SerialNumber string `json:"serialNumber,omitempty"`
 }
 
 type DomainCertificates map[string]Certificate
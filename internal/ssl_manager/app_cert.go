@@ -0,0 +1,121 @@
+package ssl_manager
+
+import (
+	"path/filepath"
+	"slices"
+
+	"github.com/jozefcipa/novus/internal/ca"
+	"github.com/jozefcipa/novus/internal/config"
+	"github.com/jozefcipa/novus/internal/fs"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/sharedtypes"
+	"github.com/jozefcipa/novus/internal/transaction"
+)
+
+// With `certificates: app` in the settings, all domains of an app share a single certificate.
+// Each domain still has its own record in the state pointing to the shared files, so Nginx and the proxy
+// don't need to know which mode is used.
+
+// App certificates are stored in ~/.novus/certs/apps/<app>, so they can't collide with the domain directories
+func appCertsDir() string {
+	return filepath.Join(paths.SSLCertificatesDir, "apps")
+}
+
+func getAppCertificateDirectory(appName string) string {
+	return filepath.Join(appCertsDir(), appName)
+}
+
+func isAppCert(cert sharedtypes.Certificate) bool {
+	return filepath.Dir(filepath.Dir(cert.CertFilePath)) == appCertsDir()
+}
+
+// Returns the domains that share the given app certificate
+func appCertDomains(cert sharedtypes.Certificate, appState *novus.AppState) []string {
+	domains := []string{}
+	for domain, storedCert := range appState.SSLCertificates {
+		if storedCert.CertFilePath == cert.CertFilePath {
+			domains = append(domains, domain)
+		}
+	}
+	slices.Sort(domains)
+
+	return domains
+}
+
+func createAppCert(conf config.NovusConfig, appState *novus.AppState) (sharedtypes.DomainCertificates, bool) {
+	domains := make([]string, 0, len(conf.Routes))
+	for _, route := range conf.Routes {
+		domains = append(domains, route.Domain)
+	}
+	slices.Sort(domains)
+
+	domainCerts := make(sharedtypes.DomainCertificates, len(domains))
+	if len(domains) == 0 {
+		return domainCerts, false
+	}
+
+	certDir := getAppCertificateDirectory(conf.AppName)
+	if isAppCertUpToDate(domains, certDir, appState) {
+		logger.Debugf("SSL certificate for app %s already exists [%s]", conf.AppName, certDir)
+		// Metadata read from the file are stored for the first domain only, so they are copied to the rest
+		cert := appState.SSLCertificates[domains[0]]
+		for _, domain := range domains {
+			appState.SSLCertificates[domain] = cert
+			domainCerts[domain] = cert
+		}
+		return domainCerts, false
+	}
+
+	cert := issueAppCert(domains, certDir, appState)
+	for _, domain := range domains {
+		domainCerts[domain] = cert
+	}
+
+	return domainCerts, true
+}
+
+// The certificate is reused only if it's valid and contains exactly the domains of the app
+func isAppCertUpToDate(domains []string, certDir string, appState *novus.AppState) bool {
+	for _, domain := range domains {
+		storedCert, exists := appState.SSLCertificates[domain]
+		if !exists || filepath.Dir(storedCert.CertFilePath) != certDir {
+			logger.Debugf("SSL certificate for domain [%s] is not part of the app certificate [%s]", domain, certDir)
+			return false
+		}
+	}
+
+	if needsRenewal(domains[0], appState) {
+		return false
+	}
+
+	cert, err := ca.ReadCertificate(appState.SSLCertificates[domains[0]].CertFilePath)
+	if err != nil {
+		return false
+	}
+	certDomains := ca.SANs(cert)
+	slices.Sort(certDomains)
+	if !slices.Equal(certDomains, domains) {
+		logger.Debugf("Domains of the app certificate have changed [%s]", certDir)
+		return false
+	}
+
+	return true
+}
+
+func issueAppCert(domains []string, certDir string, appState *novus.AppState) sharedtypes.Certificate {
+	transaction.TrackDir(certDir)
+	fs.MakeDirOrExit(certDir)
+
+	logger.Debugf("Creating SSL certificate for %d domains [%s]", len(domains), certDir)
+	cert := ca.IssueCertificate(domains, certDir)
+
+	for _, domain := range domains {
+		appState.SSLCertificates[domain] = cert
+	}
+
+	logger.Debugf("SSL certificate generated [%s]", certDir)
+
+	return cert
+}
@@ -1,17 +1,22 @@
 package ssl_manager
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
 	"time"
 
+	"github.com/jozefcipa/novus/internal/ca"
 	"github.com/jozefcipa/novus/internal/config"
+	"github.com/jozefcipa/novus/internal/dry_run"
 	"github.com/jozefcipa/novus/internal/fs"
 	"github.com/jozefcipa/novus/internal/logger"
 	"github.com/jozefcipa/novus/internal/maputils"
-	"github.com/jozefcipa/novus/internal/mkcert"
 	"github.com/jozefcipa/novus/internal/novus"
 	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/settings"
 	"github.com/jozefcipa/novus/internal/sharedtypes"
+	"github.com/jozefcipa/novus/internal/transaction"
 )
 
 func EnsureSSLCertificates(conf config.NovusConfig, novusState *novus.NovusState, appName string) (sharedtypes.DomainCertificates, bool) {
@@ -39,6 +44,10 @@ func EnsureSSLCertificates(conf config.NovusConfig, novusState *novus.NovusState
 }
 
 func createCertsForConfig(conf config.NovusConfig, appState *novus.AppState) (sharedtypes.DomainCertificates, bool) {
+	if settings.UseAppCertificates() {
+		return createAppCert(conf, appState)
+	}
+
 	domainCerts := make(sharedtypes.DomainCertificates, len(conf.Routes))
 	hasNewCerts := false
 
@@ -58,44 +67,161 @@ func getCertificateDirectory(domain string) string {
 }
 
 func createCert(domain string, appState *novus.AppState) (sharedtypes.Certificate, bool) {
-	timeNow := time.Now()
-
-	// Check if the certificate already exists
-	storedCert, exists := appState.SSLCertificates[domain]
-	if exists {
-		// Check certificate expiration
-		// If the certificate expires in less than a month, we will renew it
-		if timeNow.After(storedCert.ExpiresAt.AddDate(0, -1, 0)) {
-			logger.Debugf("SSL certificate for domain [%s] expires in <1 month [%s]", domain, storedCert.CertFilePath)
-		} else {
-			logger.Debugf("SSL certificate for domain %s already exists [%s]", domain, storedCert.CertFilePath)
-			return storedCert, false
-		}
+	// Check if the certificate already exists (an app certificate is replaced when switching to per-domain certificates)
+	if storedCert, exists := appState.SSLCertificates[domain]; exists && !isAppCert(storedCert) && !needsRenewal(domain, appState) {
+		logger.Debugf("SSL certificate for domain %s already exists [%s]", domain, storedCert.CertFilePath)
+		return appState.SSLCertificates[domain], false
+	}
+
+	return issueCert(domain, appState), true
+}
+
+// RenewCert reissues the certificate of the domain if it's about to expire, or always if forced
+func RenewCert(domain string, appState *novus.AppState, force bool) (sharedtypes.Certificate, bool) {
+	if !force && !needsRenewal(domain, appState) {
+		logger.Debugf("SSL certificate for domain %s is valid, skipping renewal", domain)
+		return appState.SSLCertificates[domain], false
+	}
+
+	// App certificate is shared by all domains of the app, so it's issued again for all of them
+	if storedCert := appState.SSLCertificates[domain]; isAppCert(storedCert) {
+		return issueAppCert(appCertDomains(storedCert, appState), filepath.Dir(storedCert.CertFilePath), appState), true
+	}
+
+	return issueCert(domain, appState), true
+}
+
+// Checks whether the stored certificate is still usable, the state is updated with the metadata read from the certificate file
+func needsRenewal(domain string, appState *novus.AppState) bool {
+	storedCert := appState.SSLCertificates[domain]
+
+	cert, err := ca.ReadCertificate(storedCert.CertFilePath)
+	if err != nil {
+		logger.Debugf("SSL certificate for domain [%s] cannot be read: %v", domain, err)
+		return true
+	}
+	if !ca.IsIssuedByRoot(cert) {
+		logger.Debugf("SSL certificate for domain [%s] is not signed by the current root CA [%s]", domain, storedCert.CertFilePath)
+		return true
+	}
+
+	// Older certificates only have an estimated expiration, so the actual one is always used
+	storedCert.ExpiresAt = cert.NotAfter
+	storedCert.SerialNumber = ca.FormatSerialNumber(cert.SerialNumber)
+	appState.SSLCertificates[domain] = storedCert
+
+	// Check certificate expiration
+	// If the certificate expires in less than a month, we will renew it
+	if time.Now().After(storedCert.ExpiresAt.AddDate(0, -1, 0)) {
+		logger.Debugf("SSL certificate for domain [%s] expires in <1 month [%s]", domain, storedCert.CertFilePath)
+		return true
 	}
 
+	return false
+}
+
+func issueCert(domain string, appState *novus.AppState) sharedtypes.Certificate {
 	// Create a directory for the domain certificate
 	domainCertDir := getCertificateDirectory(domain)
+	transaction.TrackDir(domainCertDir)
 	fs.MakeDirOrExit(domainCertDir)
 
 	// Generate certificate
 	logger.Debugf("Creating SSL certificate [%s]", domain)
-	cert := mkcert.GenerateSSLCert(domain, domainCertDir)
+	cert := ca.IssueCertificate([]string{domain}, domainCertDir)
 
 	// Save cert in state
 	appState.SSLCertificates[domain] = cert
 
 	logger.Debugf("SSL certificate generated [%s]", domain)
 
-	return cert, true
+	return cert
 }
 
 func DeleteCert(domain string, appState *novus.AppState) {
 	logger.Debugf("Deleting SSL certificate [%s]", domain)
 
+	// App certificate is only deleted with the last domain, otherwise it's issued again without the domain on the next serve
+	if storedCert, exists := appState.SSLCertificates[domain]; exists && isAppCert(storedCert) && len(appCertDomains(storedCert, appState)) > 1 {
+		delete(appState.SSLCertificates, domain)
+		return
+	}
+
 	// Remove directory with SSL certificate for the given domain
 	domainCertDir := getCertificateDirectory(domain)
-	fs.DeleteDir(domainCertDir)
+	if storedCert, exists := appState.SSLCertificates[domain]; exists && isAppCert(storedCert) {
+		domainCertDir = filepath.Dir(storedCert.CertFilePath)
+	}
+	if dry_run.Enabled {
+		dry_run.Record(dry_run.Action{Type: dry_run.DeleteCertificate, Target: fmt.Sprintf("%s [%s]", domain, domainCertDir)})
+	} else {
+		transaction.TrackDir(domainCertDir)
+		fs.DeleteDir(domainCertDir)
+	}
 
 	// Remove cert from state
 	delete(appState.SSLCertificates, domain)
 }
+
+// RevokeCert deletes the certificate files of the domain and removes the certificate from the state,
+// so a new one is issued on the next serve. App certificates are revoked for all domains sharing them.
+// Returns the domains whose certificate has been revoked.
+func RevokeCert(domain string, appState *novus.AppState) []string {
+	storedCert := appState.SSLCertificates[domain]
+
+	domains := []string{domain}
+	certDir := getCertificateDirectory(domain)
+	if isAppCert(storedCert) {
+		domains = appCertDomains(storedCert, appState)
+		certDir = filepath.Dir(storedCert.CertFilePath)
+	}
+
+	logger.Debugf("Revoking SSL certificate [domains=%v, dir=%s]", domains, certDir)
+	if dry_run.Enabled {
+		dry_run.Record(dry_run.Action{Type: dry_run.DeleteCertificate, Target: fmt.Sprintf("%s [%s]", domain, certDir)})
+	} else {
+		transaction.TrackDir(certDir)
+		fs.DeleteDir(certDir)
+	}
+
+	for _, revokedDomain := range domains {
+		delete(appState.SSLCertificates, revokedDomain)
+	}
+
+	return domains
+}
+
+// UnusedCertificateDirs returns the directories in ~/.novus/certs that no certificate in the state is stored in
+func UnusedCertificateDirs(novusState novus.NovusState) []string {
+	entries, err := os.ReadDir(paths.SSLCertificatesDir)
+	if err != nil {
+		logger.Debugf("Failed to read %s: %v", paths.SSLCertificatesDir, err)
+		return []string{}
+	}
+
+	usedDirs := map[string]bool{}
+	for _, appState := range novusState.Apps {
+		for _, cert := range appState.SSLCertificates {
+			usedDirs[filepath.Dir(cert.CertFilePath)] = true
+		}
+	}
+
+	unusedDirs := []string{}
+	for _, entry := range entries {
+		dir := filepath.Join(paths.SSLCertificatesDir, entry.Name())
+		if entry.IsDir() && dir != appCertsDir() && !usedDirs[dir] {
+			unusedDirs = append(unusedDirs, dir)
+		}
+	}
+
+	// App certificates are stored in a subdirectory
+	appEntries, _ := os.ReadDir(appCertsDir())
+	for _, entry := range appEntries {
+		dir := filepath.Join(appCertsDir(), entry.Name())
+		if entry.IsDir() && !usedDirs[dir] {
+			unusedDirs = append(unusedDirs, dir)
+		}
+	}
+
+	return unusedDirs
+}
@@ -0,0 +1,208 @@
+package ssl_manager
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/ca"
+	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/sharedtypes"
+)
+
+func writePEM(t *testing.T, path string, blockType string, der []byte) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+// Creates a CA certificate and its key, the certificate is written to certPath
+func createTestCA(t *testing.T, certPath string, keyPath string) (*x509.Certificate, crypto.Signer) {
+	t.Helper()
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Novus test CA"},
+		NotBefore:             time.Now().AddDate(-5, 0, 0),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("Failed to create the CA certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	writePEM(t, certPath, "CERTIFICATE", der)
+	if keyPath != "" {
+		keyDER, _ := x509.MarshalPKCS8PrivateKey(key)
+		writePEM(t, keyPath, "PRIVATE KEY", keyDER)
+	}
+
+	return cert, key
+}
+
+// Issues a certificate for the domain that expires at the given time and returns its path
+func issueTestCert(t *testing.T, domain string, expiresAt time.Time, issuer *x509.Certificate, issuerKey crypto.Signer) string {
+	t.Helper()
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		DNSNames:     []string{domain},
+		NotBefore:    time.Now().AddDate(-2, 0, 0),
+		NotAfter:     expiresAt,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, key.Public(), issuerKey)
+	if err != nil {
+		t.Fatalf("Failed to create a certificate for %s: %v", domain, err)
+	}
+
+	certPath := filepath.Join(t.TempDir(), domain, "cert.pem")
+	writePEM(t, certPath, "CERTIFICATE", der)
+
+	return certPath
+}
+
+func TestNeedsRenewal(t *testing.T) {
+	stateDir := t.TempDir()
+	paths.NovusSettingsFilePath = filepath.Join(stateDir, "settings.yml")
+	paths.CACertFilePath = filepath.Join(stateDir, "ca/rootCA.pem")
+	paths.CAKeyFilePath = filepath.Join(stateDir, "ca/rootCA-key.pem")
+
+	rootCert, rootKey := createTestCA(t, paths.CACertFilePath, paths.CAKeyFilePath)
+	if !ca.Load() {
+		t.Fatalf("Failed to load the test CA")
+	}
+	otherCert, otherKey := createTestCA(t, filepath.Join(stateDir, "other/rootCA.pem"), "")
+
+	tests := []struct {
+		name     string
+		certPath string
+		want     bool
+	}{
+		{
+			name:     "valid certificate",
+			certPath: issueTestCert(t, "api.test", time.Now().AddDate(2, 0, 0), rootCert, rootKey),
+			want:     false,
+		},
+		{
+			name:     "expires in more than a month",
+			certPath: issueTestCert(t, "api.test", time.Now().AddDate(0, 1, 7), rootCert, rootKey),
+			want:     false,
+		},
+		{
+			name:     "expires in less than a month",
+			certPath: issueTestCert(t, "api.test", time.Now().AddDate(0, 0, 20), rootCert, rootKey),
+			want:     true,
+		},
+		{
+			name:     "expired",
+			certPath: issueTestCert(t, "api.test", time.Now().AddDate(0, 0, -1), rootCert, rootKey),
+			want:     true,
+		},
+		{
+			name:     "issued by another CA",
+			certPath: issueTestCert(t, "api.test", time.Now().AddDate(2, 0, 0), otherCert, otherKey),
+			want:     true,
+		},
+		{
+			name:     "missing certificate file",
+			certPath: filepath.Join(stateDir, "certs/api.test/cert.pem"),
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appState := &novus.AppState{
+				SSLCertificates: sharedtypes.DomainCertificates{
+					"api.test": {CertFilePath: tt.certPath, ExpiresAt: time.Now().AddDate(5, 0, 0)},
+				},
+			}
+
+			if renew := needsRenewal("api.test", appState); renew != tt.want {
+				t.Errorf("needsRenewal() = %t, want %t", renew, tt.want)
+			}
+
+			// The stored (possibly estimated) expiration of valid certificates is replaced with the actual one
+			if !tt.want {
+				cert, _ := ca.ReadCertificate(tt.certPath)
+				if storedExpiration := appState.SSLCertificates["api.test"].ExpiresAt; !storedExpiration.Equal(cert.NotAfter) {
+					t.Errorf("Stored expiration = %s, want %s", storedExpiration, cert.NotAfter)
+				}
+			}
+		})
+	}
+}
+
+func TestUnusedCertificateDirs(t *testing.T) {
+	paths.SSLCertificatesDir = t.TempDir()
+	for _, dir := range []string{"api.test", "web.test", "old.test", "apps/shop", "apps/old-app"} {
+		if err := os.MkdirAll(filepath.Join(paths.SSLCertificatesDir, dir), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	// Files are not certificate directories
+	if err := os.WriteFile(filepath.Join(paths.SSLCertificatesDir, "notes.txt"), []byte{}, 0644); err != nil {
+		t.Fatalf("Failed to write a file: %v", err)
+	}
+
+	certFile := func(dir string) sharedtypes.Certificate {
+		return sharedtypes.Certificate{CertFilePath: filepath.Join(paths.SSLCertificatesDir, dir, "cert.pem")}
+	}
+	novusState := novus.NovusState{
+		Apps: map[string]*novus.AppState{
+			"api": {
+				Status:          novus.APP_ACTIVE,
+				SSLCertificates: sharedtypes.DomainCertificates{"api.test": certFile("api.test")},
+			},
+			// Paused apps keep their certificates
+			"web": {
+				Status:          novus.APP_PAUSED,
+				SSLCertificates: sharedtypes.DomainCertificates{"web.test": certFile("web.test")},
+			},
+			// App certificate shared by all domains of the app
+			"shop": {
+				Status: novus.APP_ACTIVE,
+				SSLCertificates: sharedtypes.DomainCertificates{
+					"shop.test":     certFile("apps/shop"),
+					"api.shop.test": certFile("apps/shop"),
+				},
+			},
+		},
+	}
+
+	unusedDirs := UnusedCertificateDirs(novusState)
+	slices.Sort(unusedDirs)
+
+	want := []string{
+		filepath.Join(paths.SSLCertificatesDir, "apps/old-app"),
+		filepath.Join(paths.SSLCertificatesDir, "old.test"),
+	}
+	if !reflect.DeepEqual(unusedDirs, want) {
+		t.Errorf("UnusedCertificateDirs() = %v, want %v", unusedDirs, want)
+	}
+}
@@ -0,0 +1,151 @@
+package stringutils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Number of unchanged lines displayed around each change
+const diffContextLines = 3
+
+type diffLine struct {
+	op   byte // ' ', '-' or '+'
+	text string
+}
+
+// Returns a unified diff (as produced by `diff -u`) of the two strings, or an empty string if they are equal
+func UnifiedDiff(oldName string, newName string, oldText string, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+
+	lines := diffLines(splitLines(oldText), splitLines(newText))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+
+	// Group changes into hunks with surrounding context
+	i := 0
+	for i < len(lines) {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+
+		start := max(i-diffContextLines, 0)
+		end := i
+		for end < len(lines) {
+			if lines[end].op != ' ' {
+				end++
+				continue
+			}
+
+			// Look ahead to see if another change is close enough to be merged into this hunk
+			nextChange := end
+			for nextChange < len(lines) && lines[nextChange].op == ' ' {
+				nextChange++
+			}
+			if nextChange == len(lines) || nextChange-end > 2*diffContextLines {
+				end = min(end+diffContextLines, len(lines))
+				break
+			}
+			end = nextChange
+		}
+
+		writeHunk(&out, lines, start, end)
+		i = end
+	}
+
+	return out.String()
+}
+
+func writeHunk(out *strings.Builder, lines []diffLine, start int, end int) {
+	// Compute line numbers of the hunk in both files
+	oldStart, newStart := 1, 1
+	for _, line := range lines[:start] {
+		if line.op != '+' {
+			oldStart++
+		}
+		if line.op != '-' {
+			newStart++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, line := range lines[start:end] {
+		if line.op != '+' {
+			oldCount++
+		}
+		if line.op != '-' {
+			newCount++
+		}
+	}
+
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+	for _, line := range lines[start:end] {
+		fmt.Fprintf(out, "%c%s\n", line.op, line.text)
+	}
+}
+
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		// An empty range points to the line before the change
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Computes a line based diff using the longest common subsequence
+func diffLines(oldLines []string, newLines []string) []diffLine {
+	n, m := len(oldLines), len(newLines)
+
+	// lcs[i][j] holds the length of the LCS of oldLines[i:] and newLines[j:]
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]diffLine, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case oldLines[i] == newLines[j]:
+			lines = append(lines, diffLine{op: ' ', text: oldLines[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{op: '-', text: oldLines[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{op: '+', text: newLines[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		lines = append(lines, diffLine{op: '-', text: oldLines[i]})
+	}
+	for ; j < m; j++ {
+		lines = append(lines, diffLine{op: '+', text: newLines[j]})
+	}
+
+	return lines
+}
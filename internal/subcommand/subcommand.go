@@ -0,0 +1,70 @@
+package subcommand
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jozefcipa/novus/internal/logger"
+)
+
+// Maximum time a single Novus command can take (e.g. generating certificates, restarting services)
+const commandTimeout = 2 * time.Minute
+
+var ansiEscapeRegex = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// Only one state-changing command can run at a time
+var commandMutex sync.Mutex
+
+// Run executes the Novus CLI with the given arguments in a separate process and returns its output.
+// This is used by long-running processes (agent, dashboard) so a failing command cannot bring them down.
+func Run(dir string, args ...string) (string, error) {
+	commandMutex.Lock()
+	defer commandMutex.Unlock()
+
+	executable, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("Failed to get novus binary path: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	logger.Debugf("Running \"novus %s\" [dir=%s]", strings.Join(args, " "), dir)
+	cmd := exec.CommandContext(ctx, executable, args...)
+	cmd.Dir = dir
+	// Run without a controlling terminal, so commands that need a sudo password fail instead of waiting for input
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	out, err := cmd.CombinedOutput()
+	output := cleanOutput(string(out))
+	if err != nil {
+		return output, fmt.Errorf("\"novus %s\" failed: %v", strings.Join(args, " "), err)
+	}
+
+	return output, nil
+}
+
+// Removes colors and spinner animations from the CLI output
+func cleanOutput(output string) string {
+	output = ansiEscapeRegex.ReplaceAllString(output, "")
+
+	lines := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		// Spinner redraws the line using carriage returns, keep only the final state
+		if idx := strings.LastIndex(line, "\r"); idx != -1 {
+			line = line[idx+1:]
+		}
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -9,9 +9,11 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/jozefcipa/novus/internal/dry_run"
 	"github.com/jozefcipa/novus/internal/fs"
 	"github.com/jozefcipa/novus/internal/logger"
 	"github.com/jozefcipa/novus/internal/paths"
+	"github.com/jozefcipa/novus/internal/process"
 )
 
 type SudoCommand string
@@ -23,6 +25,16 @@ const (
 	RemoveFile SudoCommand = "rm"
 	Chown      SudoCommand = "chown"
 	Touch      SudoCommand = "touch"
+	Nginx      SudoCommand = "nginx"
+)
+
+// Nginx actions allowed by the sudo helper, no other arguments can be passed to Nginx
+type NginxAction string
+
+const (
+	NginxTest   NginxAction = "test"
+	NginxReload NginxAction = "reload"
+	NginxReopen NginxAction = "reopen"
 )
 
 var hasSudoersFile bool
@@ -50,22 +62,41 @@ func ensureSudoHelper() {
 	if !fs.FileExists(paths.SudoHelperPath) {
 		logger.Debugf("Sudo helper doesn't exist, creating one now.")
 		createSudoHelper(paths.SudoAllowedPaths)
+	} else if !isSudoHelperUpToDate() {
+		// Helpers created by older Novus versions might not support all the commands
+		logger.Debugf("Sudo helper is outdated, updating it now.")
+		createSudoHelper(paths.SudoAllowedPaths)
 	}
 
 	hasSudoersFile = true
 }
 
-func createSudoHelper(allowedPaths []string) {
+func renderSudoHelper(allowedPaths []string) string {
 	// Read sudo helper template content
 	sudoHelperContent := fs.ReadFileOrExit(filepath.Join(paths.AssetsDir, "sudo-helper.template.sh"))
 
 	// Replace variables
-	sudoHelperContent = strings.ReplaceAll(
+	return strings.ReplaceAll(
 		sudoHelperContent,
 		"--ALLOWED-PATHS--",
 		// Define all directories that can be modified by passwordless `sudo`
 		strings.Join(allowedPaths, " "),
 	)
+}
+
+func isSudoHelperUpToDate() bool {
+	content, err := fs.ReadFile(paths.SudoHelperPath)
+	return err == nil && content == renderSudoHelper(paths.SudoAllowedPaths)
+}
+
+// IsPasswordless returns true if the sudo helper can be run without a password (see `novus trust`),
+// this is needed for commands that run in the background and can't prompt for the password
+func IsPasswordless() bool {
+	return fs.FileExists(paths.SudoersFilePath) && isSudoHelperUpToDate()
+}
+
+func createSudoHelper(allowedPaths []string) {
+	sudoHelperContent := renderSudoHelper(allowedPaths)
 
 	// Create sudo helper file
 	logger.Infof("Creating sudo helper...")
@@ -75,20 +106,20 @@ func createSudoHelper(allowedPaths []string) {
 	logger.Debugf("Creating sudo helper directory [%s]", sudoHelperDir)
 	if _, err := exec.Command("sudo", "mkdir", "-p", sudoHelperDir).Output(); err != nil {
 		logger.Errorf("Failed to create directory %s\n	 Reason: %v", sudoHelperDir, err)
-		os.Exit(1)
+		process.Exit(1)
 	}
 
 	// Change the ownership of the directory to root
 	logger.Debugf("Changing ownership of sudo helper directory to root")
 	if _, err := exec.Command("sudo", "chown", "root", sudoHelperDir).Output(); err != nil {
 		logger.Errorf("Failed to call `sudo chown root %s`\n   Reason: %v", sudoHelperDir, err)
-		os.Exit(1)
+		process.Exit(1)
 	}
 
 	logger.Debugf("Creating sudo helper file [%s]", paths.SudoHelperPath)
 	if _, err := exec.Command("sudo", "touch", paths.SudoHelperPath).Output(); err != nil {
 		logger.Errorf("Failed to create file %s\n   Reason: %v", paths.SudoHelperPath, err)
-		os.Exit(1)
+		process.Exit(1)
 	}
 
 	// We need to change the file owner to the current user in order to be able to write to the file
@@ -96,13 +127,13 @@ func createSudoHelper(allowedPaths []string) {
 	logger.Debugf("Temporarily changing ownership of sudo helper to %s", user.Username)
 	if _, err := exec.Command("sudo", "chown", user.Username, paths.SudoHelperPath).Output(); err != nil {
 		logger.Errorf("Failed to call `sudo chown %s %s`\n   Reason: %v", user.Username, paths.SudoHelperPath, err)
-		os.Exit(1)
+		process.Exit(1)
 	}
 
 	logger.Debugf("Writing to sudo helper file [%s]", paths.SudoHelperPath)
 	if err := os.WriteFile(paths.SudoHelperPath, []byte(sudoHelperContent), 0644); err != nil {
 		logger.Errorf("Failed to write to a file %s\n   Reason: %v", paths.SudoHelperPath, err)
-		os.Exit(1)
+		process.Exit(1)
 	}
 
 	// Make it executable
@@ -110,7 +141,7 @@ func createSudoHelper(allowedPaths []string) {
 	err := os.Chmod(paths.SudoHelperPath, 0744)
 	if err != nil {
 		logger.Errorf("Failed to make sudo helper executable: %v", err)
-		os.Exit(1)
+		process.Exit(1)
 	}
 
 	// Change the sudo helper ownership to root to avoid direct file modification by users,
@@ -118,7 +149,7 @@ func createSudoHelper(allowedPaths []string) {
 	logger.Debugf("Changing ownership of sudo helper to root")
 	if _, err := exec.Command("sudo", "chown", "root", paths.SudoHelperPath).Output(); err != nil {
 		logger.Errorf("Failed to call `sudo chown root %s`\n   Reason: %v", paths.SudoHelperPath, err)
-		os.Exit(1)
+		process.Exit(1)
 	}
 	logger.Debugf("Sudo helper has been created.")
 }
@@ -149,22 +180,43 @@ func CheckPortsOrExit(ports []string) string {
 	// https://stackoverflow.com/a/29843137/4480179
 	if err != nil && result != "" {
 		logger.Errorf("Failed to run \"%s\": %v\n%s", commandString, err, result)
-		os.Exit(1)
+		process.Exit(1)
 	}
 
 	return result
 }
 
+// NginxCommand returns the command that runs the Nginx action as root via the sudo helper,
+// so that it's covered by the passwordless sudoers rule
+func NginxCommand(action NginxAction) *exec.Cmd {
+	ensureSudoHelper()
+
+	commandString := []string{paths.SudoHelperPath, string(Nginx), string(action)}
+	logger.Debugf("Running \"sudo %s\"", strings.Join(commandString, " "))
+
+	return exec.Command("sudo", commandString...)
+}
+
 func MakeDirOrExit(filePath string) {
+	if dry_run.Enabled {
+		dry_run.RecordDirCreate(filePath, true)
+		return
+	}
+
 	ensureSudoHelper()
 
 	if err := sudo(MakeDir, []string{filePath}); err != nil {
 		logger.Errorf("Failed to create directory %s\n  Reason: %v", filePath, err)
-		os.Exit(1)
+		process.Exit(1)
 	}
 }
 
 func DeleteFile(filePath string) error {
+	if dry_run.Enabled {
+		dry_run.RecordFileDelete(filePath, true)
+		return nil
+	}
+
 	ensureSudoHelper()
 
 	if err := sudo(RemoveFile, []string{filePath}); err != nil {
@@ -175,29 +227,47 @@ func DeleteFile(filePath string) error {
 }
 
 func ChownOrExit(userName string, filePath string) {
+	if dry_run.Enabled {
+		dry_run.Record(dry_run.Action{Type: dry_run.ChangeOwner, Target: fmt.Sprintf("%s (%s)", filePath, userName), Sudo: true})
+		return
+	}
+
 	ensureSudoHelper()
 
 	if err := sudo(Chown, []string{userName, filePath}); err != nil {
 		logger.Errorf("Failed to call `chown` on file %s\n  Reason: %v", filePath, err)
-		os.Exit(1)
+		process.Exit(1)
 	}
 }
 
 func WriteFileOrExit(filePath string, data string) {
+	if err := WriteFile(filePath, data); err != nil {
+		logger.Errorf(err.Error())
+		process.Exit(1)
+	}
+}
+
+func WriteFile(filePath string, data string) error {
+	if dry_run.Enabled {
+		dry_run.RecordFileWrite(filePath, data, true)
+		return nil
+	}
+
 	ensureSudoHelper()
 
 	if err := sudo(Touch, []string{filePath}); err != nil {
-		logger.Errorf("Failed to create file %s\n  Reason: %v", filePath, err)
-		os.Exit(1)
+		return errors.New(fmt.Sprintf("Failed to create file %s\n  Reason: %v", filePath, err))
 	}
 
 	// We need to change the file owner to the current user in order to be able to write to the file
 	user, _ := user.Current()
-	ChownOrExit(user.Username, filePath)
-	err := os.WriteFile(filePath, []byte(data), 0644)
+	if err := sudo(Chown, []string{user.Username, filePath}); err != nil {
+		return errors.New(fmt.Sprintf("Failed to call `chown` on file %s\n  Reason: %v", filePath, err))
+	}
 
-	if err != nil {
-		logger.Errorf("Failed to write to a file %s\n  Reason: %v", filePath, err)
-		os.Exit(1)
+	if err := os.WriteFile(filePath, []byte(data), 0644); err != nil {
+		return errors.New(fmt.Sprintf("Failed to write to a file %s\n  Reason: %v", filePath, err))
 	}
+
+	return nil
 }
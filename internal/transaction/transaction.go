@@ -0,0 +1,281 @@
+package transaction
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/jozefcipa/novus/internal/dry_run"
+	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/process"
+	"github.com/jozefcipa/novus/internal/sudo"
+)
+
+// Transaction keeps a snapshot of every file that Novus touches while applying changes,
+// so that the system can be restored to the last good state if any of the steps fails
+type transaction struct {
+	snapshots []snapshot
+	// Functions that bring services back to the restored state (e.g. restart Nginx)
+	rollbackHooks     []func()
+	rollbackHookNames []string
+}
+
+type snapshot struct {
+	path string
+	// Whether the file has been created by root (e.g. /etc/resolver/*) and needs sudo to be restored
+	privileged bool
+	existed    bool
+	isDir      bool
+	content    []byte
+	mode       os.FileMode
+	// Files of the snapshotted directory (only used when isDir = true)
+	dirFiles map[string]fileSnapshot
+}
+
+type fileSnapshot struct {
+	content []byte
+	mode    os.FileMode
+}
+
+var active *transaction
+
+// Begin starts tracking changes.
+// If the program exits with an error before calling Commit(), all tracked files are restored.
+func Begin() {
+	if dry_run.Enabled || active != nil {
+		return
+	}
+
+	logger.Debugf("Starting transaction")
+	active = &transaction{}
+	process.OnFailure(rollback)
+}
+
+// Commit marks all the changes as successfully applied, so they won't be rolled back anymore
+func Commit() {
+	if active == nil {
+		return
+	}
+
+	logger.Debugf("Transaction committed [%d files changed]", len(active.snapshots))
+	active = nil
+}
+
+// TrackFile takes a snapshot of the file before Novus modifies or deletes it
+func TrackFile(path string) {
+	track(path, false)
+}
+
+// TrackPrivilegedFile takes a snapshot of a root-owned file that can be only restored via sudo
+func TrackPrivilegedFile(path string) {
+	track(path, true)
+}
+
+// TrackDir takes a snapshot of all files in a directory (non-recursively)
+func TrackDir(path string) {
+	if active == nil || isTracked(path) {
+		return
+	}
+
+	snap := snapshot{path: path, isDir: true, dirFiles: map[string]fileSnapshot{}}
+	if entries, err := os.ReadDir(path); err == nil {
+		snap.existed = true
+		for _, entry := range entries {
+			if !entry.Type().IsRegular() {
+				continue
+			}
+			content, err := os.ReadFile(filepath.Join(path, entry.Name()))
+			info, infoErr := entry.Info()
+			if err == nil && infoErr == nil {
+				snap.dirFiles[entry.Name()] = fileSnapshot{content: content, mode: info.Mode().Perm()}
+			}
+		}
+	}
+
+	logger.Debugf("Transaction: tracking directory [%s, existed=%t]", path, snap.existed)
+	active.snapshots = append(active.snapshots, snap)
+}
+
+// OnRollback registers a function that is called after the files have been restored.
+// The name is used to avoid registering the same hook multiple times.
+func OnRollback(name string, hook func()) {
+	if active == nil || slices.Contains(active.rollbackHookNames, name) {
+		return
+	}
+
+	active.rollbackHookNames = append(active.rollbackHookNames, name)
+	active.rollbackHooks = append(active.rollbackHooks, hook)
+}
+
+func track(path string, privileged bool) {
+	if active == nil || isTracked(path) {
+		return
+	}
+
+	snap := snapshot{path: path, privileged: privileged}
+	if content, err := os.ReadFile(path); err == nil {
+		snap.existed = true
+		snap.content = content
+		snap.mode = 0644
+		if info, err := os.Stat(path); err == nil {
+			snap.mode = info.Mode().Perm()
+		}
+	}
+
+	logger.Debugf("Transaction: tracking file [%s, existed=%t]", path, snap.existed)
+	active.snapshots = append(active.snapshots, snap)
+}
+
+func isTracked(path string) bool {
+	return slices.ContainsFunc(active.snapshots, func(snap snapshot) bool { return snap.path == path })
+}
+
+func rollback() {
+	if active == nil {
+		return
+	}
+	tx := active
+	active = nil
+
+	if len(tx.snapshots) == 0 {
+		return
+	}
+
+	logger.Warnf("Applying changes failed, rolling back...")
+
+	restored := []string{}
+	removed := []string{}
+	failed := []string{}
+
+	// Restore files in the reverse order
+	for i := len(tx.snapshots) - 1; i >= 0; i-- {
+		snap := tx.snapshots[i]
+
+		var changed bool
+		var err error
+		if snap.existed {
+			changed, err = restore(snap)
+		} else {
+			changed, err = remove(snap)
+		}
+
+		switch {
+		case err != nil:
+			logger.Debugf("Failed to roll back %s: %v", snap.path, err)
+			failed = append(failed, snap.path)
+		case !changed:
+			logger.Debugf("File has not been changed, nothing to roll back [%s]", snap.path)
+		case snap.existed:
+			restored = append(restored, snap.path)
+		default:
+			removed = append(removed, snap.path)
+		}
+	}
+
+	for _, path := range restored {
+		logger.Infof("   ↺ restored %s", path)
+	}
+	for _, path := range removed {
+		logger.Infof("   ↺ removed %s", path)
+	}
+	for _, path := range failed {
+		logger.Errorf("Failed to restore %s", path)
+	}
+
+	// Restart services so they pick up the restored configuration
+	for _, hook := range tx.rollbackHooks {
+		hook()
+	}
+
+	if len(failed) == 0 {
+		logger.Checkf("All changes have been rolled back")
+	} else {
+		logger.Hintf("Some files could not be restored, run \"novus serve\" again to fix the configuration.")
+	}
+}
+
+// Writes the original content back, returns false if the file hasn't been changed
+func restore(snap snapshot) (bool, error) {
+	if snap.isDir {
+		return restoreDir(snap)
+	}
+
+	if isUnchanged(snap.path, fileSnapshot{content: snap.content, mode: snap.mode}) {
+		return false, nil
+	}
+
+	if snap.privileged {
+		return true, sudo.WriteFile(snap.path, string(snap.content))
+	}
+
+	return true, writeFile(snap.path, fileSnapshot{content: snap.content, mode: snap.mode})
+}
+
+// Restores the snapshotted files and deletes the ones created during the transaction
+func restoreDir(snap snapshot) (bool, error) {
+	if err := os.MkdirAll(snap.path, os.ModePerm); err != nil {
+		return false, err
+	}
+
+	changed := false
+	if entries, err := os.ReadDir(snap.path); err == nil {
+		for _, entry := range entries {
+			if _, exists := snap.dirFiles[entry.Name()]; exists || !entry.Type().IsRegular() {
+				continue
+			}
+			if err := os.Remove(filepath.Join(snap.path, entry.Name())); err != nil {
+				return changed, err
+			}
+			changed = true
+		}
+	}
+
+	for name, file := range snap.dirFiles {
+		filePath := filepath.Join(snap.path, name)
+		if isUnchanged(filePath, file) {
+			continue
+		}
+		if err := writeFile(filePath, file); err != nil {
+			return changed, err
+		}
+		changed = true
+	}
+
+	return changed, nil
+}
+
+func isUnchanged(path string, file fileSnapshot) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != file.mode {
+		return false
+	}
+
+	current, err := os.ReadFile(path)
+	return err == nil && string(current) == string(file.content)
+}
+
+// os.WriteFile only sets the permissions of new files, so they are set explicitly (e.g. 0600 for private keys)
+func writeFile(path string, file fileSnapshot) error {
+	if err := os.WriteFile(path, file.content, file.mode); err != nil {
+		return err
+	}
+
+	return os.Chmod(path, file.mode)
+}
+
+// Deletes a file that has been created during the transaction, returns false if there was nothing to delete
+func remove(snap snapshot) (bool, error) {
+	if _, err := os.Stat(snap.path); err != nil {
+		return false, nil
+	}
+
+	if snap.isDir {
+		return true, os.RemoveAll(snap.path)
+	}
+
+	if snap.privileged {
+		return true, sudo.DeleteFile(snap.path)
+	}
+
+	return true, os.Remove(snap.path)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/jozefcipa/novus/internal/logger"
 	"github.com/jozefcipa/novus/internal/maputils"
 	"github.com/jozefcipa/novus/internal/novus"
+	"github.com/jozefcipa/novus/internal/process"
 	"github.com/olekukonko/tablewriter"
 )
 
@@ -23,7 +24,7 @@ func AskUser(prompt string) string {
 	err := scanner.Err()
 	if err != nil {
 		logger.Errorf("Failed to read from CLI: %v", err)
-		os.Exit(1)
+		process.Exit(1)
 	}
 
 	return scanner.Text()
@@ -108,13 +109,13 @@ func ParseAppFromArgs(args []string, cmd string) (string, *novus.AppState) {
 	if len(args) < 1 {
 		logger.Errorf("App name not provided!")
 		logger.Hintf("Please specify app name by running \"novus %s [app-name]\"", cmd)
-		os.Exit(1)
+		process.Exit(1)
 	}
 	appName := args[0]
 
 	if appName == novus.NovusInternalAppName || appName == novus.GlobalAppName {
 		logger.Errorf("App \"%s\" is used by Novus and cannot be %sd", appName, cmd)
-		os.Exit(1)
+		process.Exit(1)
 	}
 
 	// Load app state for the given app name if it exists, or throw an error
@@ -1,11 +1,11 @@
 package validation
 
 import (
-	"os"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/process"
 	"github.com/jozefcipa/novus/internal/tld"
 )
 
@@ -23,6 +23,6 @@ func RegisterNonExistentTLDValidator(validate *validator.Validate) {
 	err := validate.RegisterValidation("existing_tld", nonExistentTLDValidator)
 	if err != nil {
 		logger.Errorf("Failed to register custom validator rule %v", err)
-		os.Exit(1)
+		process.Exit(1)
 	}
 }
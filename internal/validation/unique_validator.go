@@ -1,12 +1,12 @@
 package validation
 
 import (
-	"os"
 	"slices"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/jozefcipa/novus/internal/logger"
+	"github.com/jozefcipa/novus/internal/process"
 	"github.com/jozefcipa/novus/internal/sharedtypes"
 )
 
@@ -31,6 +31,6 @@ func RegisterUniqueRoutesValidator(validate *validator.Validate) {
 	err := validate.RegisterValidation("unique_routes", uniqueRoutesValidator)
 	if err != nil {
 		logger.Errorf("Failed to register custom validator rule %v", err)
-		os.Exit(1)
+		process.Exit(1)
 	}
 }